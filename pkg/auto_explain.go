@@ -0,0 +1,295 @@
+package pkg
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+type LogFormat = string
+
+const (
+	LogFormatStderr = LogFormat("stderr")
+	LogFormatCSV    = LogFormat("csvlog")
+)
+
+// Position of the columns in the csvlog format, they are stable across Postgres versions
+const (
+	csvLogTimeColumn     = 0
+	csvLogUserColumn     = 1
+	csvLogDatabaseColumn = 2
+	csvLogMessageColumn  = 13
+)
+
+var (
+	autoExplainMessageRegex   = regexp.MustCompile(`(?s)^duration: (\d+(?:\.\d+)?) ms\s+plan:\s*(.*)$`)
+	autoExplainSeverityRegex  = regexp.MustCompile(`\b(?:LOG|INFO|NOTICE|WARNING|DEBUG\d?):\s+`)
+	autoExplainTimestampRegex = regexp.MustCompile(`^\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}(?:\.\d+)?(?: (?:[A-Z]{2,5}|[+-]\d{2}(?::?\d{2})?))?`)
+	autoExplainUserRegex      = regexp.MustCompile(`\buser=([^,\s]+)`)
+	autoExplainDatabaseRegex  = regexp.MustCompile(`\bdb=([^,\s]+)`)
+	autoExplainUserAtDBRegex  = regexp.MustCompile(`(?:^|\s)([\w.-]+)@([\w.-]+)(?:\s|$)`)
+)
+
+var autoExplainTimestampLayouts = []string{
+	"2006-01-02 15:04:05 MST",
+	"2006-01-02 15:04:05 -07",
+	"2006-01-02 15:04:05 -07:00",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05",
+}
+
+// AutoExplainReader extracts the plans logged by the auto_explain extension from a Postgres server log
+type AutoExplainReader struct {
+	format LogFormat
+}
+
+func NewAutoExplainReader(format LogFormat) *AutoExplainReader {
+	return &AutoExplainReader{
+		format: format,
+	}
+}
+
+// ExplainLog reads every auto_explain plan in the log and runs it through Explain, a plan that
+// can't be explained is reported in its entry Error without stopping the others
+func ExplainLog(log io.Reader, format LogFormat, opts ExplainOptions) ([]ExplainedLogEntry, error) {
+	entries, err := NewAutoExplainReader(format).Read(log)
+	if err != nil {
+		return nil, err
+	}
+
+	explainedEntries := make([]ExplainedLogEntry, 0, len(entries))
+	for _, entry := range entries {
+		explainedEntry := ExplainedLogEntry{AutoExplainEntry: entry}
+
+		explained, err := Explain(entry.Plan, opts)
+		if err != nil {
+			explainedEntry.Error = err.Error()
+		} else {
+			explainedEntry.Explained = explained
+		}
+
+		explainedEntries = append(explainedEntries, explainedEntry)
+	}
+
+	return explainedEntries, nil
+}
+
+func (r *AutoExplainReader) Read(log io.Reader) ([]AutoExplainEntry, error) {
+	switch r.format {
+	case LogFormatStderr:
+		return r.readStderr(log)
+	case LogFormatCSV:
+		return r.readCSV(log)
+	default:
+		return nil, fmt.Errorf("unsupported log format: %v", r.format)
+	}
+}
+
+// readStderr groups the lines of each message, Postgres prefixes the continuation lines with a tab
+func (r *AutoExplainReader) readStderr(log io.Reader) ([]AutoExplainEntry, error) {
+	entries := make([]AutoExplainEntry, 0)
+	scanner := bufio.NewScanner(log)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+
+	var prefix string
+	var message *strings.Builder
+
+	flush := func() {
+		if message == nil {
+			return
+		}
+		if entry, ok := parseAutoExplainMessage(message.String()); ok {
+			entry.Timestamp = parseAutoExplainTimestamp(autoExplainTimestampRegex.FindString(prefix))
+			entry.User, entry.Database = parseAutoExplainUserAndDatabase(prefix)
+			entries = append(entries, entry)
+		}
+		message = nil
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if strings.HasPrefix(line, "\t") {
+			if message != nil {
+				message.WriteString("\n")
+				message.WriteString(strings.TrimPrefix(line, "\t"))
+			}
+			continue
+		}
+
+		flush()
+
+		location := autoExplainSeverityRegex.FindStringIndex(line)
+		if location == nil {
+			continue
+		}
+		prefix = line[:location[0]]
+		message = &strings.Builder{}
+		message.WriteString(line[location[1]:])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not read log: %v", err)
+	}
+
+	flush()
+
+	return entries, nil
+}
+
+func (r *AutoExplainReader) readCSV(log io.Reader) ([]AutoExplainEntry, error) {
+	entries := make([]AutoExplainEntry, 0)
+	reader := csv.NewReader(log)
+	reader.FieldsPerRecord = -1
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not read csv log: %v", err)
+		}
+		if len(record) <= csvLogMessageColumn {
+			continue
+		}
+
+		entry, ok := parseAutoExplainMessage(record[csvLogMessageColumn])
+		if !ok {
+			continue
+		}
+
+		entry.Timestamp = parseAutoExplainTimestamp(record[csvLogTimeColumn])
+		entry.User = record[csvLogUserColumn]
+		entry.Database = record[csvLogDatabaseColumn]
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// parseAutoExplainMessage returns false when the message has not been logged by auto_explain. A plan that can't
+// be parsed is kept as it is, Explain will report the error for that entry only
+func parseAutoExplainMessage(message string) (AutoExplainEntry, bool) {
+	match := autoExplainMessageRegex.FindStringSubmatch(message)
+	if match == nil {
+		return AutoExplainEntry{}, false
+	}
+
+	// The regex only matches numbers
+	duration, _ := ConvertStringToFloat64(match[1])
+
+	entry := AutoExplainEntry{
+		Duration: duration,
+		Plan:     strings.TrimSpace(match[2]),
+	}
+
+	plans, queryText, err := parseAutoExplainPlan(entry.Plan)
+	if err != nil {
+		return entry, true
+	}
+
+	marshal, err := json.Marshal(plans)
+	if err != nil {
+		return entry, true
+	}
+
+	entry.Plan = string(marshal)
+	entry.QueryText = queryText
+
+	return entry, true
+}
+
+// parseAutoExplainPlan handles every auto_explain.log_format, the query text is logged inside the plan
+func parseAutoExplainPlan(body string) ([]interface{}, string, error) {
+	var plans []interface{}
+
+	switch {
+	case strings.HasPrefix(body, "{"):
+		var plan Node
+		if err := json.Unmarshal([]byte(body), &plan); err != nil {
+			return nil, "", err
+		}
+		plans = []interface{}{plan}
+	case strings.HasPrefix(body, "<"):
+		p, err := ParseXMLPlan(body)
+		if err != nil {
+			return nil, "", err
+		}
+		plans = p
+	case strings.HasPrefix(body, `Query Text: "`):
+		// FORMAT YAML logs a single mapping instead of a list
+		p, err := ParseYAMLPlan("- " + strings.ReplaceAll(body, "\n", "\n  "))
+		if err != nil {
+			return nil, "", err
+		}
+		plans = p
+	default:
+		return parseAutoExplainTextPlan(body)
+	}
+
+	if len(plans) == 0 {
+		return nil, "", fmt.Errorf("no plan found")
+	}
+
+	root, ok := plans[0].(Node)
+	if !ok {
+		return nil, "", fmt.Errorf("expected a query, got %v", describeValue(plans[0]))
+	}
+	queryText, _ := root["Query Text"].(string)
+
+	return plans, queryText, nil
+}
+
+// parseAutoExplainTextPlan splits the query text, that can span several lines, from the plan that follows it
+func parseAutoExplainTextPlan(body string) ([]interface{}, string, error) {
+	lines := strings.Split(body, "\n")
+	queryLines := make([]string, 0)
+
+	for i, line := range lines {
+		if isTextNodeHeader(line) {
+			plans, err := ParseTextPlan(strings.Join(lines[i:], "\n"))
+			if err != nil {
+				return nil, "", err
+			}
+			return plans, strings.TrimPrefix(strings.Join(queryLines, "\n"), "Query Text: "), nil
+		}
+		queryLines = append(queryLines, line)
+	}
+
+	return nil, "", fmt.Errorf("no plan found")
+}
+
+func parseAutoExplainTimestamp(timestamp string) time.Time {
+	for _, layout := range autoExplainTimestampLayouts {
+		if t, err := time.Parse(layout, timestamp); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// parseAutoExplainUserAndDatabase supports the usual log_line_prefix escapes: "%u@%d" and "user=%u,db=%d"
+func parseAutoExplainUserAndDatabase(prefix string) (string, string) {
+	user, database := "", ""
+
+	if match := autoExplainUserRegex.FindStringSubmatch(prefix); match != nil {
+		user = match[1]
+	}
+	if match := autoExplainDatabaseRegex.FindStringSubmatch(prefix); match != nil {
+		database = match[1]
+	}
+	if user != "" || database != "" {
+		return user, database
+	}
+
+	if match := autoExplainUserAtDBRegex.FindStringSubmatch(prefix); match != nil {
+		return match[1], match[2]
+	}
+
+	return "", ""
+}
@@ -0,0 +1,142 @@
+package pkg
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const autoExplainTestStderrLog = `2024-03-01 10:15:00.123 UTC [4242] app@shop LOG:  connection authorized: user=app database=shop
+2024-03-01 10:15:01.456 UTC [4242] app@shop LOG:  duration: 12.345 ms  plan:
+	{
+	  "Query Text": "select * from orders where id = 1;",
+	  "Plan": {
+	    "Node Type": "Index Scan",
+	    "Parallel Aware": false,
+	    "Scan Direction": "Forward",
+	    "Index Name": "orders_pkey",
+	    "Relation Name": "orders",
+	    "Alias": "orders",
+	    "Startup Cost": 0.29,
+	    "Total Cost": 8.31,
+	    "Plan Rows": 1,
+	    "Plan Width": 40,
+	    "Actual Startup Time": 0.010,
+	    "Actual Total Time": 0.011,
+	    "Actual Rows": 1,
+	    "Actual Loops": 1,
+	    "Index Cond": "(id = 1)",
+	    "Rows Removed by Index Recheck": 0
+	  }
+	}
+2024-03-01 10:15:02.000 UTC [4243] report@analytics LOG:  duration: 1500.000 ms  plan:
+	Query Text: select count(*)
+	from events;
+	Aggregate  (cost=10.00..10.01 rows=1 width=8) (actual time=1499.000..1499.001 rows=1 loops=1)
+	  ->  Seq Scan on events  (cost=0.00..9.00 rows=400 width=0) (actual time=0.010..1200.000 rows=400 loops=1)
+2024-03-01 10:15:03.000 UTC [4244] app@shop LOG:  duration: 3.000 ms  plan:
+	{ "Query Text": "broken"
+2024-03-01 10:15:04.000 UTC [4244] app@shop ERROR:  relation "foo" does not exist
+`
+
+const autoExplainTestMalformedLog = `2024-03-01 10:15:01.000 UTC [4242] app@shop LOG:  duration: 1.000 ms  plan:
+	Query Text: select 1;
+	Result  (cost=0.00..0.01 rows=1 width=4) (actual time=0.001..0.001 rows=1 loops=1)
+2024-03-01 10:15:02.000 UTC [4242] app@shop LOG:  duration: 2.000 ms  plan:
+	<explain xmlns="http://www.postgresql.org/2009/explain"><Query>oops</Query></explain>
+2024-03-01 10:15:03.000 UTC [4242] app@shop LOG:  duration: 3.000 ms  plan:
+	Query Text: select 2;
+	Result  (cost=0.00..0.01 rows=1 width=4) (actual time=0.001..0.001 rows=1 loops=1)
+`
+
+const autoExplainTestCSVLog = `2024-03-01 10:15:01.456 UTC,"app","shop",4242,"[local]",65e1aa,1,"SELECT",2024-03-01 10:14:00 UTC,3/10,0,LOG,00000,"duration: 0.020 ms  plan:
+{
+  ""Query Text"": ""select 1;"",
+  ""Plan"": {
+    ""Node Type"": ""Result"",
+    ""Parallel Aware"": false,
+    ""Startup Cost"": 0.00,
+    ""Total Cost"": 0.01,
+    ""Plan Rows"": 1,
+    ""Plan Width"": 4,
+    ""Actual Startup Time"": 0.001,
+    ""Actual Total Time"": 0.001,
+    ""Actual Rows"": 1,
+    ""Actual Loops"": 1
+  }
+}",,,,,,,,,"psql","client backend",,0
+2024-03-01 10:15:02.000 UTC,"app","shop",4242,"[local]",65e1aa,2,"idle",2024-03-01 10:14:00 UTC,3/0,0,LOG,00000,"disconnection: session time: 0:00:02.000",,,,,,,,,"psql","client backend",,0
+`
+
+func TestExplainLog(t *testing.T) {
+	type want struct {
+		duration  float64
+		user      string
+		database  string
+		queryText string
+		rows      int
+		hasError  bool
+	}
+	tests := []struct {
+		name   string
+		log    string
+		format LogFormat
+		want   []want
+	}{
+		{
+			name:   "stderr",
+			log:    autoExplainTestStderrLog,
+			format: LogFormatStderr,
+			want: []want{
+				{duration: 12.345, user: "app", database: "shop", queryText: "select * from orders where id = 1;", rows: 1},
+				{duration: 1500, user: "report", database: "analytics", queryText: "select count(*)\nfrom events;", rows: 2},
+				{duration: 3, user: "app", database: "shop", hasError: true},
+			},
+		},
+		{
+			name:   "malformed xml between two plans",
+			log:    autoExplainTestMalformedLog,
+			format: LogFormatStderr,
+			want: []want{
+				{duration: 1, user: "app", database: "shop", queryText: "select 1;", rows: 1},
+				{duration: 2, user: "app", database: "shop", hasError: true},
+				{duration: 3, user: "app", database: "shop", queryText: "select 2;", rows: 1},
+			},
+		},
+		{
+			name:   "csvlog",
+			log:    autoExplainTestCSVLog,
+			format: LogFormatCSV,
+			want: []want{
+				{duration: 0.02, user: "app", database: "shop", queryText: "select 1;", rows: 1},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExplainLog(strings.NewReader(tt.log), tt.format, ExplainOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("ExplainLog() entries = %v, want %v", len(got), len(tt.want))
+			}
+
+			for i, w := range tt.want {
+				entry := got[i]
+				if entry.Duration != w.duration || entry.User != w.user || entry.Database != w.database || entry.QueryText != w.queryText {
+					t.Errorf("ExplainLog() entry %v = %+v, want %+v", i, entry.AutoExplainEntry, w)
+				}
+				if entry.Timestamp.Year() != 2024 || entry.Timestamp.Month() != time.March {
+					t.Errorf("ExplainLog() entry %v timestamp = %v", i, entry.Timestamp)
+				}
+				if (entry.Error != "") != w.hasError {
+					t.Errorf("ExplainLog() entry %v error = %v, want error %v", i, entry.Error, w.hasError)
+				}
+				if len(entry.Explained.Summary) != w.rows {
+					t.Errorf("ExplainLog() entry %v summary rows = %v, want %v", i, len(entry.Explained.Summary), w.rows)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,433 @@
+package pkg
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// The Custom Scan providers of Citus, the ones of the older executors included
+var citusProviders = []string{
+	"Citus Adaptive",
+	"Citus Router",
+	"Citus Real-Time",
+	"Citus Task-Tracker",
+	"Citus INSERT ... SELECT",
+	"Citus MERGE INTO ...",
+}
+
+func init() {
+	for _, provider := range citusProviders {
+		RegisterCustomScan(provider, OperationDescriptor{
+			RelationName: RELATION_NAME,
+			Properties:   getDistributedQueryProperties,
+		})
+	}
+}
+
+// RemoteTask is a task of a Citus distributed query. The plan is the one reported by the worker node, its nodes are
+// tagged with the task. Extra keeps the other properties of the task, e.g. "Tuple data received from node".
+type RemoteTask struct {
+	TaskInfo
+	Plan          *PlanNode
+	PlanningTime  float64
+	ExecutionTime float64
+	Extra         Node
+}
+
+// Time is the execution time reported by the worker node, the time of the root of the plan when it's missing
+func (t *RemoteTask) Time() float64 {
+	if t.ExecutionTime > 0 {
+		return t.ExecutionTime
+	}
+	return t.Plan.InclusiveDuration
+}
+
+// decodeDistributedQuery decodes the tasks of the Job of a distributed query, its other properties, e.g. the Task
+// Count, are kept in Extra as if they had been reported by the node itself. The subplans become InitPlans of the node.
+func (n *PlanNode) decodeDistributedQuery(value interface{}) error {
+	path := propertyPath(n.Path, DISTRIBUTED_QUERY)
+	query, ok := value.(map[string]interface{})
+	if !ok {
+		return newPlanError(n.Path, DISTRIBUTED_QUERY, "object", value)
+	}
+
+	if query[DISTRIBUTED_SUBPLANS] != nil {
+		if err := n.decodeDistributedSubplans(path, query[DISTRIBUTED_SUBPLANS]); err != nil {
+			return err
+		}
+	}
+
+	if query[JOB] == nil {
+		return nil
+	}
+	job, err := objectProperty(path, query, JOB)
+	if err != nil {
+		return err
+	}
+
+	path = propertyPath(path, JOB)
+	for key, value := range job {
+		if key != TASKS {
+			n.Extra[key] = value
+		}
+	}
+
+	if job[TASKS] == nil {
+		return nil
+	}
+	tasks, ok := job[TASKS].([]interface{})
+	if !ok {
+		return newPlanError(path, TASKS, "array of tasks", job[TASKS])
+	}
+
+	n.Tasks = make([]*RemoteTask, 0, len(tasks))
+	for i, value := range tasks {
+		task, err := decodeRemoteTask(childPath(path, TASKS, i), i, value)
+		if err != nil {
+			return err
+		}
+		n.Tasks = append(n.Tasks, task)
+	}
+
+	return nil
+}
+
+// decodeDistributedSubplans decodes the subplans the coordinator runs before the distributed query, e.g. for a CTE,
+// their results are sent to the worker nodes. Their other properties, e.g. the Intermediate Data Size, are kept in
+// the Extra of the root of their plan.
+func (n *PlanNode) decodeDistributedSubplans(path string, value interface{}) error {
+	subplans, ok := value.([]interface{})
+	if !ok {
+		return newPlanError(path, DISTRIBUTED_SUBPLANS, "array of subplans", value)
+	}
+
+	for i, value := range subplans {
+		subplanPath := childPath(path, DISTRIBUTED_SUBPLANS, i)
+		raw, ok := value.(map[string]interface{})
+		if !ok {
+			return newPlanError(subplanPath, "", "subplan", value)
+		}
+
+		plan, ok := remotePlan(raw[PLANNED_STMT])
+		if !ok {
+			return newPlanError(subplanPath, PLANNED_STMT, "plan", raw[PLANNED_STMT])
+		}
+		root, err := objectProperty(propertyPath(subplanPath, PLANNED_STMT), plan, "Plan")
+		if err != nil {
+			return err
+		}
+		subplan, err := newPlanNode(root, propertyPath(subplanPath, PLANNED_STMT))
+		if err != nil {
+			return err
+		}
+
+		subplan.ParentRelationship = "InitPlan"
+		subplan.SubplanName = fmt.Sprintf("Distributed Subplan %d", i+1)
+		for key, value := range raw {
+			if key != PLANNED_STMT {
+				subplan.Extra[key] = value
+			}
+		}
+		n.Plans = append(n.Plans, subplan)
+	}
+
+	return nil
+}
+
+func isDistributedSubplan(node *PlanNode) bool {
+	return node.ParentRelationship == "InitPlan" && strings.HasPrefix(node.SubplanName, "Distributed Subplan")
+}
+
+func decodeRemoteTask(path string, index int, value interface{}) (*RemoteTask, error) {
+	raw, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, newPlanError(path, "", "task", value)
+	}
+
+	task := &RemoteTask{TaskInfo: TaskInfo{Index: index}, Extra: Node{}}
+	for key, value := range raw {
+		if key != WORKER_NODE && key != REMOTE_PLAN {
+			task.Extra[key] = value
+		}
+	}
+
+	if raw[WORKER_NODE] != nil {
+		node, err := stringProperty(path, raw, WORKER_NODE)
+		if err != nil {
+			return nil, err
+		}
+		task.Node = node
+	}
+
+	// The worker node reports the plan as EXPLAIN (FORMAT JSON) would, a list of queries holding the plan and
+	// its times, Citus wraps it in another list
+	plan, ok := remotePlan(raw[REMOTE_PLAN])
+	if !ok {
+		return nil, newPlanError(path, REMOTE_PLAN, "plan", raw[REMOTE_PLAN])
+	}
+
+	for _, time := range []struct {
+		value *float64
+		key   string
+	}{
+		{&task.PlanningTime, PLANNING_TIME},
+		{&task.ExecutionTime, EXECUTION_TIME},
+	} {
+		if plan[time.key] == nil {
+			continue
+		}
+
+		value, err := floatProperty(propertyPath(path, REMOTE_PLAN), plan, time.key)
+		if err != nil {
+			return nil, err
+		}
+		*time.value = value
+	}
+
+	root, err := objectProperty(propertyPath(path, REMOTE_PLAN), plan, "Plan")
+	if err != nil {
+		return nil, err
+	}
+	if task.Plan, err = newPlanNode(root, propertyPath(path, REMOTE_PLAN)); err != nil {
+		return nil, err
+	}
+	tagTask(task.Plan, &task.TaskInfo)
+
+	return task, nil
+}
+
+func remotePlan(value interface{}) (Node, bool) {
+	for {
+		switch v := value.(type) {
+		case []interface{}:
+			if len(v) == 0 {
+				return nil, false
+			}
+			value = v[0]
+		case map[string]interface{}:
+			return v, true
+		default:
+			return nil, false
+		}
+	}
+}
+
+func tagTask(node *PlanNode, task *TaskInfo) {
+	node.Task = task
+	for _, subNode := range node.Plans {
+		tagTask(subNode, task)
+	}
+}
+
+func getDistributedQueryProperties(node *PlanNode) ([]Property, error) {
+	props := make([]Property, 0)
+
+	if node.Extra[TASK_COUNT] != nil {
+		taskCount, err := floatProperty(node.Path, node.Extra, TASK_COUNT)
+		if err != nil {
+			return nil, err
+		}
+		props = append(props, Property{ID: "task_count", Name: TASK_COUNT, Type: "float", ValueFloat: taskCount, Kind: Quantity})
+	}
+
+	for _, property := range []Property{
+		{ID: "tasks_shown", Name: TASKS_SHOWN},
+		{ID: "data_received", Name: "Tuple data received from nodes"},
+	} {
+		if node.Extra[property.Name] == nil {
+			continue
+		}
+
+		value, err := stringProperty(node.Path, node.Extra, property.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		property.Type = "string"
+		property.ValueString = value
+		props = append(props, property)
+	}
+
+	return props, nil
+}
+
+// ComputeDistributedStats describes the Citus distributed queries of the plan, nil when there are none
+func (s *StatsGather) ComputeDistributedStats(node *PlanNode) (*DistributedStats, error) {
+	stats := &DistributedStats{
+		Queries: make([]DistributedQueryStats, 0),
+		Nodes:   make([]WorkerNodeStats, 0),
+	}
+
+	nodes := map[string]*WorkerNodeStats{}
+	if err := s.computeDistributedStats(node, stats, nodes); err != nil {
+		return nil, err
+	}
+
+	if len(stats.Queries) == 0 {
+		return nil, nil
+	}
+
+	totalTime := 0.0
+	for _, workerNode := range nodes {
+		totalTime += workerNode.TotalTime
+	}
+	for _, workerNode := range nodes {
+		workerNode.Percentage = getPercentage(workerNode.TotalTime, totalTime)
+		stats.Nodes = append(stats.Nodes, *workerNode)
+	}
+	sort.Slice(stats.Nodes, func(i, j int) bool {
+		if stats.Nodes[i].TotalTime != stats.Nodes[j].TotalTime {
+			return stats.Nodes[i].TotalTime > stats.Nodes[j].TotalTime
+		}
+		return stats.Nodes[i].Node < stats.Nodes[j].Node
+	})
+
+	return stats, nil
+}
+
+func (s *StatsGather) computeDistributedStats(node *PlanNode, stats *DistributedStats, nodes map[string]*WorkerNodeStats) error {
+	if node.Tasks != nil {
+		query := DistributedQueryStats{
+			Id:         node.NodeId,
+			TasksShown: len(node.Tasks),
+		}
+		query.Provider, _ = node.Extra[CUSTOM_PLAN_PROVIDER].(string)
+
+		query.TaskCount = len(node.Tasks)
+		if node.Extra[TASK_COUNT] != nil {
+			taskCount, err := floatProperty(node.Path, node.Extra, TASK_COUNT)
+			if err != nil {
+				return err
+			}
+			query.TaskCount = int(taskCount)
+		}
+
+		for _, task := range node.Tasks {
+			query.TotalTime += task.Time()
+
+			workerNode, ok := nodes[task.Node]
+			if !ok {
+				workerNode = &WorkerNodeStats{Node: task.Node}
+				nodes[task.Node] = workerNode
+			}
+			workerNode.Tasks++
+			workerNode.TotalTime += task.Time()
+			workerNode.Rows += task.Plan.ActualRowsRevised
+			if task.Time() > workerNode.MaxTime {
+				workerNode.MaxTime = task.Time()
+			}
+		}
+
+		stats.Queries = append(stats.Queries, query)
+	}
+
+	for _, subNode := range node.Plans {
+		if err := s.computeDistributedStats(subNode, stats, nodes); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// textDistributedQueries moves the Task and the Distributed Subplan nodes of the TEXT format into the "Distributed
+// Query" of their Custom Scan, where FORMAT JSON reports them
+func textDistributedQueries(node Node) {
+	children, _ := node[PLANS_PROP].([]interface{})
+
+	plans, tasks, subplans := make([]interface{}, 0, len(children)), make([]interface{}, 0), make([]interface{}, 0)
+	for _, child := range children {
+		childNode := child.(Node)
+		if nodeType, _ := childNode[NODE_TYPE].(string); strings.HasPrefix(nodeType, "Distributed Subplan ") {
+			subplans = append(subplans, textDistributedSubplan(childNode))
+			continue
+		}
+		if childNode[NODE_TYPE] != "Task" {
+			textDistributedQueries(childNode)
+			plans = append(plans, child)
+			continue
+		}
+
+		task, plan := Node{}, Node{}
+		for key, value := range childNode {
+			switch key {
+			case NODE_TYPE, PARENT_RELATIONSHIP, PARALLEL_AWARE:
+			case PLANNING_TIME, EXECUTION_TIME:
+				plan[key] = value
+			case PLANS_PROP:
+				if remote, _ := value.([]interface{}); len(remote) > 0 {
+					root := remote[0].(Node)
+					delete(root, PARENT_RELATIONSHIP)
+					textDistributedQueries(root)
+					plan["Plan"] = root
+				}
+			default:
+				task[key] = value
+			}
+		}
+		task[REMOTE_PLAN] = []interface{}{[]interface{}{plan}}
+		tasks = append(tasks, task)
+	}
+
+	if len(tasks) == 0 && len(subplans) == 0 {
+		return
+	}
+
+	query := Node{}
+	if len(tasks) > 0 {
+		query[JOB] = Node{TASKS: tasks}
+	}
+	if len(subplans) > 0 {
+		query[DISTRIBUTED_SUBPLANS] = subplans
+	}
+	node[DISTRIBUTED_QUERY] = query
+	if len(plans) == 0 {
+		delete(node, PLANS_PROP)
+	} else {
+		node[PLANS_PROP] = plans
+	}
+}
+
+// textDistributedSubplan turns a "Distributed Subplan 1_1" node into a subplan as FORMAT JSON reports it, its
+// properties, e.g. the Intermediate Data Size, beside the plan
+func textDistributedSubplan(node Node) Node {
+	subplan, plan := Node{}, Node{}
+	for key, value := range node {
+		switch key {
+		case NODE_TYPE, PARENT_RELATIONSHIP, PARALLEL_AWARE:
+		case PLANS_PROP:
+			if children, _ := value.([]interface{}); len(children) > 0 {
+				root := children[0].(Node)
+				delete(root, PARENT_RELATIONSHIP)
+				textDistributedQueries(root)
+				plan["Plan"] = root
+			}
+		default:
+			subplan[key] = value
+		}
+	}
+	subplan[PLANNED_STMT] = []interface{}{plan}
+	return subplan
+}
+
+// describeTask names the worker node of a task, e.g. "host=10.0.0.1 port=5432 dbname=app" becomes 10.0.0.1:5432
+func describeTask(task *TaskInfo) string {
+	host, port := "", ""
+	for _, field := range strings.Fields(task.Node) {
+		if value := strings.TrimPrefix(field, "host="); value != field {
+			host = value
+		}
+		if value := strings.TrimPrefix(field, "port="); value != field {
+			port = value
+		}
+	}
+
+	if host == "" {
+		return fmt.Sprintf("Task %d on %v", task.Index+1, task.Node)
+	}
+	if port != "" {
+		host += ":" + port
+	}
+	return fmt.Sprintf("Task %d on %v", task.Index+1, host)
+}
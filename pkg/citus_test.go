@@ -0,0 +1,162 @@
+package pkg
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestExplain_DistributedQuery(t *testing.T) {
+	tests := []struct {
+		name         string
+		plan         string
+		wantRows     []string
+		wantTasks    []string
+		wantTaskRows int
+		wantTables   []string
+		want         *DistributedStats
+	}{
+		{
+			name: "json",
+			plan: `[{"Plan":{"Node Type":"Custom Scan","Custom Plan Provider":"Citus Adaptive","Parallel Aware":false,"Startup Cost":0,"Total Cost":0,"Plan Rows":100000,"Plan Width":8,"Actual Startup Time":10,"Actual Total Time":10,"Actual Rows":2,"Actual Loops":1,` +
+				`"Distributed Query":{"Job":{"Task Count":32,"Tasks Shown":"All","Tasks":[` +
+				`{"Node":"host=10.0.0.1 port=5432 dbname=app","Remote Plan":[[{"Plan":{"Node Type":"Aggregate","Strategy":"Plain","Total Cost":2,"Plan Rows":1,"Actual Startup Time":3,"Actual Total Time":3,"Actual Rows":1,"Actual Loops":1,"Plans":[` +
+				`{"Node Type":"Seq Scan","Parent Relationship":"Outer","Relation Name":"events_102008","Alias":"events","Total Cost":1,"Plan Rows":10,"Actual Startup Time":0,"Actual Total Time":2,"Actual Rows":10,"Actual Loops":1}]},"Planning Time":0.1,"Execution Time":4}]]},` +
+				`{"Node":"host=10.0.0.2 port=5432 dbname=app","Remote Plan":[[{"Plan":{"Node Type":"Result","Total Cost":1,"Plan Rows":1,"Actual Startup Time":1,"Actual Total Time":1,"Actual Rows":1,"Actual Loops":1}}]]}]}}},"Execution Time":11}]`,
+			wantRows:     []string{"Custom Scan", "Aggregate", "Seq Scan", "Result"},
+			wantTasks:    []string{"", "Task 1 on 10.0.0.1:5432", "", "Task 2 on 10.0.0.2:5432"},
+			wantTaskRows: 3,
+			wantTables:   []string{"events_102008"},
+			want: &DistributedStats{
+				Queries: []DistributedQueryStats{{Provider: "Citus Adaptive", TaskCount: 32, TasksShown: 2, TotalTime: 5}},
+				Nodes: []WorkerNodeStats{
+					{Node: "host=10.0.0.1 port=5432 dbname=app", Tasks: 1, TotalTime: 4, MaxTime: 4, Rows: 1, Percentage: 80},
+					{Node: "host=10.0.0.2 port=5432 dbname=app", Tasks: 1, TotalTime: 1, MaxTime: 1, Rows: 1, Percentage: 20},
+				},
+			},
+		},
+		{
+			name: "text",
+			plan: `Custom Scan (Citus Adaptive)  (cost=0.00..0.00 rows=100000 width=8) (actual time=10.000..10.000 rows=1 loops=1)
+  Task Count: 32
+  Tuple data received from nodes: 8 bytes
+  Tasks Shown: One of 32
+  ->  Task
+        Tuple data received from node: 8 bytes
+        Node: host=10.0.0.1 port=5432 dbname=app
+        ->  Aggregate  (cost=1.00..2.00 rows=1 width=8) (actual time=3.000..3.000 rows=1 loops=1)
+              ->  Seq Scan on events_102008 events  (cost=0.00..1.00 rows=10 width=0) (actual time=0.000..2.000 rows=10 loops=1)
+            Planning Time: 0.100 ms
+            Execution Time: 4.000 ms
+Planning Time: 1.000 ms
+Execution Time: 11.000 ms`,
+			wantRows:     []string{"Custom Scan", "Aggregate", "Seq Scan"},
+			wantTasks:    []string{"", "Task 1 on 10.0.0.1:5432", ""},
+			wantTaskRows: 2,
+			wantTables:   []string{"events_102008"},
+			want: &DistributedStats{
+				Queries: []DistributedQueryStats{{Provider: "Citus Adaptive", TaskCount: 32, TasksShown: 1, TotalTime: 4}},
+				Nodes: []WorkerNodeStats{
+					{Node: "host=10.0.0.1 port=5432 dbname=app", Tasks: 1, TotalTime: 4, MaxTime: 4, Rows: 1, Percentage: 100},
+				},
+			},
+		},
+		{
+			name: "json subplans",
+			plan: `[{"Plan":{"Node Type":"Custom Scan","Custom Plan Provider":"Citus Adaptive","Total Cost":0,"Plan Rows":100000,"Actual Startup Time":5,"Actual Total Time":5,"Actual Rows":1,"Actual Loops":1,` +
+				`"Distributed Query":{"Subplans":[{"Intermediate Data Size":"18 bytes","Result destination":"Send to 1 nodes","PlannedStmt":[{"Plan":{"Node Type":"Limit","Total Cost":0.1,"Plan Rows":1,"Actual Startup Time":1,"Actual Total Time":1,"Actual Rows":1,"Actual Loops":1,"Plans":[` +
+				`{"Node Type":"Seq Scan","Parent Relationship":"Outer","Relation Name":"users_102040","Alias":"users","Total Cost":1,"Plan Rows":10,"Actual Startup Time":0.5,"Actual Total Time":0.5,"Actual Rows":1,"Actual Loops":1}]}}]}],` +
+				`"Job":{"Task Count":1,"Tasks Shown":"All","Tasks":[{"Node":"host=10.0.0.1 port=5432 dbname=app","Remote Plan":[[{"Plan":{"Node Type":"Seq Scan","Relation Name":"events_102008","Alias":"events","Total Cost":1,"Plan Rows":10,"Actual Startup Time":0,"Actual Total Time":2,"Actual Rows":10,"Actual Loops":1},"Execution Time":2}]]}]}}},"Execution Time":6}]`,
+			wantRows:     []string{"Custom Scan", "Limit", "Seq Scan", "Seq Scan"},
+			wantTasks:    []string{"", "Distributed Subplan 1", "", "Task 1 on 10.0.0.1:5432"},
+			wantTaskRows: 1,
+			wantTables:   []string{"events_102008", "users_102040"},
+			want: &DistributedStats{
+				Queries: []DistributedQueryStats{{Provider: "Citus Adaptive", TaskCount: 1, TasksShown: 1, TotalTime: 2}},
+				Nodes: []WorkerNodeStats{
+					{Node: "host=10.0.0.1 port=5432 dbname=app", Tasks: 1, TotalTime: 2, MaxTime: 2, Rows: 10, Percentage: 100},
+				},
+			},
+		},
+		{
+			name: "text subplans",
+			plan: `Custom Scan (Citus Adaptive)  (cost=0.00..0.00 rows=100000 width=8) (actual time=5.000..5.000 rows=1 loops=1)
+  ->  Distributed Subplan 1_1
+        Intermediate Data Size: 18 bytes
+        Result destination: Send to 1 nodes
+        ->  Limit  (cost=0.00..0.10 rows=1 width=8) (actual time=1.000..1.000 rows=1 loops=1)
+              ->  Seq Scan on users_102040 users  (cost=0.00..1.00 rows=10 width=8) (actual time=0.500..0.500 rows=1 loops=1)
+  Task Count: 1
+  Tasks Shown: All
+  ->  Task
+        Node: host=10.0.0.1 port=5432 dbname=app
+        ->  Seq Scan on events_102008 events  (cost=0.00..1.00 rows=10 width=0) (actual time=0.000..2.000 rows=10 loops=1)
+            Execution Time: 2.000 ms
+Execution Time: 6.000 ms`,
+			wantRows:     []string{"Custom Scan", "Limit", "Seq Scan", "Seq Scan"},
+			wantTasks:    []string{"", "Distributed Subplan 1", "", "Task 1 on 10.0.0.1:5432"},
+			wantTaskRows: 1,
+			wantTables:   []string{"events_102008", "users_102040"},
+			want: &DistributedStats{
+				Queries: []DistributedQueryStats{{Provider: "Citus Adaptive", TaskCount: 1, TasksShown: 1, TotalTime: 2}},
+				Nodes: []WorkerNodeStats{
+					{Node: "host=10.0.0.1 port=5432 dbname=app", Tasks: 1, TotalTime: 2, MaxTime: 2, Rows: 10, Percentage: 100},
+				},
+			},
+		},
+		{
+			name:       "without distributed query",
+			plan:       `[{"Plan":{"Node Type":"Seq Scan","Relation Name":"events","Total Cost":10,"Plan Rows":10}}]`,
+			wantRows:   []string{"Seq Scan"},
+			wantTasks:  []string{""},
+			wantTables: []string{"events"},
+			want:       nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			explained, err := Explain(tt.plan, ExplainOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rows, tasks, taskRows := make([]string, 0), make([]string, 0), 0
+			for _, row := range explained.Summary {
+				rows = append(rows, row.Operation)
+				tasks = append(tasks, row.SubPlanOf)
+				if row.Task != nil {
+					taskRows++
+				}
+			}
+			if !reflect.DeepEqual(rows, tt.wantRows) || !reflect.DeepEqual(tasks, tt.wantTasks) {
+				t.Errorf("rows = %v %v, want %v %v", rows, tasks, tt.wantRows, tt.wantTasks)
+			}
+			if taskRows != tt.wantTaskRows {
+				t.Errorf("rows of the tasks = %v, want %v", taskRows, tt.wantTaskRows)
+			}
+
+			// The shards scanned by the tasks are tables of their own
+			tables := make([]string, 0)
+			for _, table := range explained.TablesStats.Tables {
+				tables = append(tables, table.Name)
+			}
+			sort.Strings(tables)
+			if !reflect.DeepEqual(tables, tt.wantTables) {
+				t.Errorf("tables = %v, want %v", tables, tt.wantTables)
+			}
+			if len(explained.Summary) > 1 && explained.Summary[1].NodeParentId != explained.Summary[0].NodeId {
+				t.Errorf("the plan of the task isn't below the distributed query")
+			}
+
+			got := explained.DistributedStats
+			if got != nil {
+				for i := range got.Queries {
+					got.Queries[i].Id = ""
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DistributedStats = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
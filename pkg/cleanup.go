@@ -0,0 +1,125 @@
+package pkg
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	cleanupSeparatorRegex    = regexp.MustCompile(`^-+(?:\+-+)*$`)
+	cleanupFooterRegex       = regexp.MustCompile(`^\(\d+ rows?\)$`)
+	cleanupContinuationRegex = regexp.MustCompile(`\s\+$`)
+)
+
+// CleanPlan strips the decoration added by psql and CSV exports around a plan: the "QUERY PLAN" header, the dashed
+// separator, the "(1 row)" footer, the trailing "+" continuation markers and the wrapping quotes.
+// The report tells what has been removed.
+func CleanPlan(plan string) (string, CleanupReport) {
+	report := CleanupReport{}
+	plan = strings.ReplaceAll(plan, "\r\n", "\n")
+	plan = unquoteCSVPlan(plan, &report)
+
+	lines := strings.Split(plan, "\n")
+	lines = trimEmptyLines(lines)
+
+	if len(lines) > 0 && strings.TrimSpace(lines[0]) == "QUERY PLAN" {
+		report.HeaderRemoved = true
+		lines = trimEmptyLines(lines[1:])
+	}
+
+	if len(lines) > 0 && cleanupSeparatorRegex.MatchString(strings.TrimSpace(lines[0])) {
+		report.SeparatorRemoved = true
+		lines = trimEmptyLines(lines[1:])
+	}
+
+	if len(lines) > 0 && cleanupFooterRegex.MatchString(strings.TrimSpace(lines[len(lines)-1])) {
+		report.FooterRemoved = true
+		lines = trimEmptyLines(lines[:len(lines)-1])
+	}
+
+	// psql marks every line of a multi-line value but the last one, a lonely "+" could be part of the plan
+	markers := 0
+	for _, line := range lines {
+		if cleanupContinuationRegex.MatchString(line) {
+			markers++
+		}
+	}
+	if markers > 0 && markers >= len(lines)-1 {
+		for i, line := range lines {
+			lines[i] = cleanupContinuationRegex.ReplaceAllString(line, "")
+		}
+		report.ContinuationMarkers = markers
+	}
+
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+
+	return strings.Join(lines, "\n"), report
+}
+
+// Removed describes what has been stripped from the plan
+func (r CleanupReport) Removed() []string {
+	removed := make([]string, 0)
+	if r.QuotesRemoved {
+		removed = append(removed, "wrapping quotes")
+	}
+	if r.HeaderRemoved {
+		removed = append(removed, "QUERY PLAN header")
+	}
+	if r.SeparatorRemoved {
+		removed = append(removed, "separator line")
+	}
+	if r.ContinuationMarkers > 0 {
+		removed = append(removed, fmt.Sprintf("%v continuation markers", r.ContinuationMarkers))
+	}
+	if r.FooterRemoved {
+		removed = append(removed, "row count footer")
+	}
+	return removed
+}
+
+// unquoteCSVPlan removes the quotes of CSV exports, either around the whole plan or around each row
+func unquoteCSVPlan(plan string, report *CleanupReport) string {
+	trimmed := strings.TrimSpace(plan)
+	if isCSVQuoted(trimmed) {
+		report.QuotesRemoved = true
+		return strings.ReplaceAll(trimmed[1:len(trimmed)-1], `""`, `"`)
+	}
+
+	lines := trimEmptyLines(strings.Split(trimmed, "\n"))
+	for _, line := range lines {
+		if !isCSVQuoted(strings.TrimSpace(line)) {
+			return plan
+		}
+	}
+
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		lines[i] = strings.ReplaceAll(line[1:len(line)-1], `""`, `"`)
+	}
+	report.QuotesRemoved = true
+
+	return strings.Join(lines, "\n")
+}
+
+func isCSVQuoted(value string) bool {
+	if len(value) < 2 || !strings.HasPrefix(value, `"`) || !strings.HasSuffix(value, `"`) {
+		return false
+	}
+
+	// The quotes inside the value are escaped by doubling them
+	inner := strings.ReplaceAll(value[1:len(value)-1], `""`, "")
+	return !strings.Contains(inner, `"`)
+}
+
+func trimEmptyLines(lines []string) []string {
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
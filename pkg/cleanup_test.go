@@ -0,0 +1,93 @@
+package pkg
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCleanPlan(t *testing.T) {
+	tests := []struct {
+		name       string
+		plan       string
+		want       string
+		wantReport CleanupReport
+	}{
+		{
+			name: "psql json plan",
+			plan: `                QUERY PLAN
+------------------------------------------
+ [                                       +
+   {                                     +
+     "Plan": {                           +
+       "Node Type": "Result"             +
+     }                                   +
+   }                                     +
+ ]
+(1 row)
+`,
+			want: ` [
+   {
+     "Plan": {
+       "Node Type": "Result"
+     }
+   }
+ ]`,
+			wantReport: CleanupReport{
+				HeaderRemoved:       true,
+				SeparatorRemoved:    true,
+				FooterRemoved:       true,
+				ContinuationMarkers: 6,
+			},
+		},
+		{
+			name: "psql text plan",
+			plan: `                         QUERY PLAN
+-------------------------------------------------------------
+ Seq Scan on t  (cost=0.00..1.01 rows=1 width=4)
+   Filter: (a > 1)
+(2 rows)`,
+			want: ` Seq Scan on t  (cost=0.00..1.01 rows=1 width=4)
+   Filter: (a > 1)`,
+			wantReport: CleanupReport{
+				HeaderRemoved:    true,
+				SeparatorRemoved: true,
+				FooterRemoved:    true,
+			},
+		},
+		{
+			name: "csv export",
+			plan: `"Seq Scan on t  (cost=0.00..1.01 rows=1 width=4)"
+"  Filter: (b = ""x"")"`,
+			want: `Seq Scan on t  (cost=0.00..1.01 rows=1 width=4)
+  Filter: (b = "x")`,
+			wantReport: CleanupReport{
+				QuotesRemoved: true,
+			},
+		},
+		{
+			name:       "clean plan",
+			plan:       `[{"Plan":{"Node Type":"Result"}}]`,
+			want:       `[{"Plan":{"Node Type":"Result"}}]`,
+			wantReport: CleanupReport{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, report := CleanPlan(tt.plan)
+			if got != tt.want {
+				t.Errorf("CleanPlan() = %q, want %q", got, tt.want)
+			}
+			if !reflect.DeepEqual(report, tt.wantReport) {
+				t.Errorf("CleanPlan() report = %+v, want %+v", report, tt.wantReport)
+			}
+
+			explained, err := Explain(tt.plan, ExplainOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(explained.Cleanup, tt.wantReport) {
+				t.Errorf("Explain() cleanup = %+v, want %+v", explained.Cleanup, tt.wantReport)
+			}
+		})
+	}
+}
@@ -26,6 +26,9 @@ func (c *Comparator) compareGeneralStats() ComparisonGeneralStats {
 		MaxBlocksRead:    getPropComparison(c.plan.Stats.MaxBlocksRead, c.planToCompare.Stats.MaxBlocksRead, true),
 		MaxBlocksWritten: getPropComparison(c.plan.Stats.MaxBlocksWritten, c.planToCompare.Stats.MaxBlocksWritten, true),
 		MaxBlocksHit:     getPropComparison(c.plan.Stats.MaxBlocksHit, c.planToCompare.Stats.MaxBlocksHit, false),
+		WALRecords:       getPropComparison(c.plan.Stats.WALRecords, c.planToCompare.Stats.WALRecords, false),
+		WALBytes:         getPropComparison(c.plan.Stats.WALBytes, c.planToCompare.Stats.WALBytes, false),
+		WALFPI:           getPropComparison(c.plan.Stats.WALFPI, c.planToCompare.Stats.WALFPI, false),
 	}
 }
 
@@ -75,6 +78,7 @@ func (c *NodeComparator) Compare() (NodeComparison, error) {
 			Total:            getPropComparison(c.node.Rows.Total, c.nodeToCompare.Rows.Total, false),
 			PlannedRows:      getPropComparison(c.node.Rows.PlannedRows, c.nodeToCompare.Rows.PlannedRows, false),
 			Removed:          getPropComparison(c.node.Rows.Removed, c.nodeToCompare.Rows.Removed, false),
+			RemovedByRecheck: getPropComparison(c.node.Rows.RemovedByRecheck, c.nodeToCompare.Rows.RemovedByRecheck, false),
 			EstimationFactor: getPropComparison(c.node.Rows.EstimationFactor, c.nodeToCompare.Rows.EstimationFactor, false),
 		},
 		Costs: CostsComparison{
@@ -94,6 +98,14 @@ func (c *NodeComparator) Compare() (NodeComparison, error) {
 		}
 	}
 
+	if c.node.DoesContainWAL {
+		comparison.WAL = WALComparison{
+			Records: getPropComparison(c.node.WAL.ExclusiveRecords, c.nodeToCompare.WAL.ExclusiveRecords, false),
+			Bytes:   getPropComparison(c.node.WAL.ExclusiveBytes, c.nodeToCompare.WAL.ExclusiveBytes, false),
+			FPI:     getPropComparison(c.node.WAL.ExclusiveFPI, c.nodeToCompare.WAL.ExclusiveFPI, false),
+		}
+	}
+
 	if c.node.Operation != c.nodeToCompare.Operation {
 		comparison.Warnings = append(
 			comparison.Warnings,
@@ -101,10 +113,16 @@ func (c *NodeComparator) Compare() (NodeComparison, error) {
 		)
 	}
 
-	if c.node.Scopes.Table != c.nodeToCompare.Scopes.Table {
+	// The schemas are only known for VERBOSE plans, a plan without them can still be compared with one that has them
+	table, tableToCompare := c.node.Scopes.Table, c.nodeToCompare.Scopes.Table
+	if c.node.Scopes.Schema != "" && c.nodeToCompare.Scopes.Schema != "" {
+		table = qualifiedName(c.node.Scopes.Schema, table)
+		tableToCompare = qualifiedName(c.nodeToCompare.Scopes.Schema, tableToCompare)
+	}
+	if table != tableToCompare {
 		comparison.Warnings = append(
 			comparison.Warnings,
-			fmt.Sprintf("Nodes are acting on different tables: %v, %v", c.node.Scopes.Table, c.nodeToCompare.Scopes.Table),
+			fmt.Sprintf("Nodes are acting on different tables: %v, %v", table, tableToCompare),
 		)
 	}
 
@@ -1,5 +1,10 @@
 package pkg
 
+import "fmt"
+
+// Below that share of lookups found in the cache a Memoize node is reported
+const memoizeLowHitRatio = 50.0
+
 var operationsMap = map[string]Operation{
 	SEQUENTIAL_SCAN: {
 		RelationName: RELATION_NAME,
@@ -7,135 +12,70 @@ var operationsMap = map[string]Operation{
 		getWorkers:   getGenericWorkers,
 	},
 	INDEX_SCAN: {
-		RelationName: RELATION_NAME,
-		Index:        INDEX_NAME,
-		Filter:       FILTER,
-		Condition:    INDEX_CONDITION,
-		getWorkers:   getGenericWorkers,
-		getSpecificProperties: func(node Node) []Property {
-			props := make([]Property, 0)
-
-			if node[HEAP_FETCHES] != nil {
-				props = append(props, Property{
-					ID:         "heap_fetches",
-					Name:       "Heap fetches",
-					Type:       "float",
-					ValueFloat: ConvertToFloat64(node[HEAP_FETCHES]),
-					Kind:       Quantity,
-				})
-			}
-
-			return props
-		},
+		RelationName:          RELATION_NAME,
+		Index:                 INDEX_NAME,
+		Filter:                FILTER,
+		Condition:             INDEX_CONDITION,
+		getWorkers:            getGenericWorkers,
+		getSpecificProperties: getHeapFetchesProperties,
 	},
 	INDEX_ONLY_SCAN: {
-		RelationName: RELATION_NAME,
-		Index:        INDEX_NAME,
-		Filter:       FILTER,
-		Condition:    INDEX_CONDITION,
-		getWorkers:   getGenericWorkers,
-		getSpecificProperties: func(node Node) []Property {
-			props := make([]Property, 0)
-
-			if node[HEAP_FETCHES] != nil {
-				props = append(props, Property{
-					ID:         "heap_fetches",
-					Name:       "Heap fetches",
-					Type:       "float",
-					ValueFloat: ConvertToFloat64(node[HEAP_FETCHES]),
-					Kind:       Quantity,
-				})
-			}
-
-			return props
-		},
+		RelationName:          RELATION_NAME,
+		Index:                 INDEX_NAME,
+		Filter:                FILTER,
+		Condition:             INDEX_CONDITION,
+		getWorkers:            getGenericWorkers,
+		getSpecificProperties: getHeapFetchesProperties,
 	},
 	SORT: {
-		Key:        SORT_KEY,
-		getWorkers: getSortWorkers,
-		getSpecificProperties: func(node Node) []Property {
+		Key:         SORT_KEY,
+		getWorkers:  getSortWorkers,
+		getInsights: getLocalSortInsights,
+		getSpecificProperties: func(node *PlanNode) ([]Property, error) {
 			props := make([]Property, 0)
-			props = getSortProperties(node, props)
-			return props
+			return getSortProperties(node.Path, node.Extra, props)
 		},
 	},
 	INCREMENTAL_SORT: {
-		Key:        SORT_KEY,
-		getWorkers: getSortWorkers,
-		getSpecificProperties: func(node Node) []Property {
+		Key:         SORT_KEY,
+		getWorkers:  getSortWorkers,
+		getInsights: getLocalSortInsights,
+		getSpecificProperties: func(node *PlanNode) ([]Property, error) {
 			props := make([]Property, 0)
-			props = getSortProperties(node, props)
+			props, err := getSortProperties(node.Path, node.Extra, props)
+			if err != nil {
+				return nil, err
+			}
+
+			if node.Extra[PRESORTED_KEY] != nil {
+				presortedKey, err := scopeProperty(node.Path, PRESORTED_KEY, node.Extra[PRESORTED_KEY])
+				if err != nil {
+					return nil, err
+				}
 
-			if node[PRESORTED_KEY] != nil {
 				props = append(props, Property{
 					ID:          "pre_sorted_key",
 					Name:        PRESORTED_KEY,
 					Type:        "string",
-					ValueString: ConvertScopeToString(node[PRESORTED_KEY]),
+					ValueString: presortedKey,
 				})
 			}
 
-			if node[PRE_SORTED_GROUPS] != nil {
-				sortedGroups := node[PRE_SORTED_GROUPS].(map[string]interface{})
-
-				props = append(props, Property{
-					ID:         "pre_sort_group_count",
-					Name:       "Pre Sort Group Count",
-					Type:       "float",
-					ValueFloat: ConvertToFloat64(sortedGroups["Group Count"]),
-					Kind:       Quantity,
-				})
-
-				if sortedGroups["Sort Space Memory"] != nil {
-					sortSpaceMemory := sortedGroups["Sort Space Memory"].(map[string]interface{})
-					props = append(props, Property{
-						ID:         "average_pre_sort_space_used",
-						Name:       "Pre Sort Average Space Used",
-						Type:       "float",
-						ValueFloat: ConvertToFloat64(sortSpaceMemory["Average Sort Space Used"]),
-						Kind:       DiskSize,
-					})
-					props = append(props, Property{
-						ID:         "peak_pre_sort_space_used",
-						Name:       "Pre Sort Peak Space Used",
-						Type:       "float",
-						ValueFloat: ConvertToFloat64(sortSpaceMemory["Peak Sort Space Used"]),
-						Kind:       DiskSize,
-					})
+			if node.Extra[PRE_SORTED_GROUPS] != nil {
+				props, err = getSortGroupsProperties(node.Path, node.Extra, PRE_SORTED_GROUPS, props)
+				if err != nil {
+					return nil, err
 				}
 			}
 
-			if node[FULL_SORT_GROUPS] != nil {
-				sortedGroups := node[FULL_SORT_GROUPS].(map[string]interface{})
-
-				props = append(props, Property{
-					ID:         "full_group_count",
-					Name:       "Full Sort Group Count",
-					Type:       "float",
-					ValueFloat: ConvertToFloat64(sortedGroups["Group Count"]),
-					Kind:       Quantity,
-				})
-
-				if sortedGroups["Sort Space Memory"] != nil {
-					sortSpaceMemory := sortedGroups["Sort Space Memory"].(map[string]interface{})
-					props = append(props, Property{
-						ID:         "average_full_sort_space_used",
-						Name:       "Full Average Sort Space Used",
-						Type:       "float",
-						ValueFloat: ConvertToFloat64(sortSpaceMemory["Average Sort Space Used"]),
-						Kind:       DiskSize,
-					})
-					props = append(props, Property{
-						ID:         "peak_full_sort_space_used",
-						Name:       "Full Sort Peak Space Used",
-						Type:       "float",
-						ValueFloat: ConvertToFloat64(sortSpaceMemory["Peak Sort Space Used"]),
-						Kind:       DiskSize,
-					})
+			if node.Extra[FULL_SORT_GROUPS] != nil {
+				props, err = getSortGroupsProperties(node.Path, node.Extra, FULL_SORT_GROUPS, props)
+				if err != nil {
+					return nil, err
 				}
 			}
 
-			return props
+			return props, nil
 		},
 	},
 	CTE_SCAN: {
@@ -155,19 +95,23 @@ var operationsMap = map[string]Operation{
 		Key: GROUP_KEY,
 	},
 	HASH_AGGREGATE: {
-		Key:        GROUP_KEY,
-		getWorkers: getHashWorkers,
+		Key:                   GROUP_KEY,
+		Filter:                FILTER,
+		getWorkers:            getHashAggregateWorkers,
+		getSpecificProperties: getHashAggregateProperties,
+		getInsights:           getHashAggregateInsights,
 	},
 	"Partial " + HASH_AGGREGATE: {
-		Key:        GROUP_KEY,
-		getWorkers: getHashWorkers,
+		Key:                   GROUP_KEY,
+		Filter:                FILTER,
+		getWorkers:            getHashAggregateWorkers,
+		getSpecificProperties: getHashAggregateProperties,
+		getInsights:           getHashAggregateInsights,
 	},
 	HASH: {
-		getSpecificProperties: func(node Node) []Property {
+		getSpecificProperties: func(node *PlanNode) ([]Property, error) {
 			props := make([]Property, 0)
-			props = append(props, hashBucketsAndBatches(node, props)...)
-
-			return props
+			return hashBucketsAndBatches(node.Path, node.Extra, props)
 		},
 		getWorkers: getGenericWorkers,
 	},
@@ -175,43 +119,110 @@ var operationsMap = map[string]Operation{
 		RelationName: RELATION_NAME,
 		Condition:    "Recheck Cond",
 		Filter:       FILTER,
-		getSpecificProperties: func(node Node) []Property {
+		getSpecificProperties: func(node *PlanNode) ([]Property, error) {
 			props := make([]Property, 0)
-			if node[HEAP_BLOCKS] != nil {
-				props = append(props, Property{
-					ID:          "heap_blocks",
-					Name:        "Heap Blocks",
-					Type:        "string",
-					ValueString: node[HEAP_BLOCKS].(string),
-				})
+			for _, property := range []Property{
+				{ID: "exact_heap_blocks", Name: EXACT_HEAP_BLOCKS, Kind: Blocks},
+				{ID: "lossy_heap_blocks", Name: LOSSY_HEAP_BLOCKS, Kind: Blocks},
+			} {
+				if node.Extra[property.Name] == nil {
+					continue
+				}
+
+				value, err := floatProperty(node.Path, node.Extra, property.Name)
+				if err != nil {
+					return nil, err
+				}
+
+				property.Type = "float"
+				property.ValueFloat = value
+				props = append(props, property)
 			}
 
-			return props
+			return props, nil
 		},
+		getInsights: getBitmapHeapScanInsights,
 	},
 	BITMAP_INDEX_SCAN: {
 		Index:     INDEX_NAME,
 		Condition: INDEX_CONDITION,
 	},
 	NESTED_LOOP_JOIN: {
-		Filter:     JOIN_FILTER,
-		getWorkers: getGenericWorkers,
+		Filter:      JOIN_FILTER,
+		getWorkers:  getGenericWorkers,
+		getInsights: getForeignJoinInsights,
 	},
 	NESTED_LOOP: {
-		Filter:     JOIN_FILTER,
-		getWorkers: getGenericWorkers,
+		Filter:      JOIN_FILTER,
+		getWorkers:  getGenericWorkers,
+		getInsights: getForeignJoinInsights,
 	},
 	NESTED_LOOP_SEMI_JOIN: {
-		Filter: JOIN_FILTER,
+		Filter:      JOIN_FILTER,
+		getInsights: getForeignJoinInsights,
 	},
 	HASH_JOIN: {
-		Filter:     JOIN_FILTER,
-		Condition:  HASH_CONDITION_PROP,
-		getWorkers: getGenericWorkers,
+		Filter:      JOIN_FILTER,
+		Condition:   HASH_CONDITION_PROP,
+		getWorkers:  getGenericWorkers,
+		getInsights: getForeignJoinInsights,
 	},
 	MERGE_JOIN: {
-		Filter:    JOIN_FILTER,
-		Condition: "Merge Cond",
+		Filter:      JOIN_FILTER,
+		Condition:   "Merge Cond",
+		getInsights: getForeignJoinInsights,
+	},
+	GATHER: {
+		getSpecificProperties: getGatherProperties,
+		getInsights:           getGatherInsights,
+	},
+	GATHER_MERGE: {
+		getSpecificProperties: getGatherProperties,
+		getInsights:           getGatherInsights,
+	},
+	APPEND: {
+		getSpecificProperties: getAppendProperties,
+		getWorkers:            getGenericWorkers,
+	},
+	MERGE_APPEND: {
+		Key:                   SORT_KEY,
+		getSpecificProperties: getAppendProperties,
+	},
+	MEMOIZE: {
+		Key:        CACHE_KEY,
+		getWorkers: getMemoizeWorkers,
+		getSpecificProperties: func(node *PlanNode) ([]Property, error) {
+			props := make([]Property, 0)
+			if node.Extra[CACHE_MODE] != nil {
+				cacheMode, err := stringProperty(node.Path, node.Extra, CACHE_MODE)
+				if err != nil {
+					return nil, err
+				}
+
+				props = append(props, Property{
+					ID:          "cache_mode",
+					Name:        CACHE_MODE,
+					Type:        "string",
+					ValueString: cacheMode,
+				})
+			}
+
+			return memoizeCacheProperties(node.Path, node.Extra, props)
+		},
+		getInsights: getMemoizeInsights,
+	},
+	FOREIGN_SCAN: {
+		RelationName:          RELATION_NAME,
+		Filter:                FILTER,
+		getSpecificProperties: getForeignScanProperties,
+		getInsights:           getForeignScanInsights,
+	},
+	MODIFY_TABLE: {
+		RelationName:          RELATION_NAME,
+		Index:                 CONFLICT_ARBITER_INDEXES,
+		Filter:                CONFLICT_FILTER,
+		Command:               DML_OPERATION,
+		getSpecificProperties: getModifyTableProperties,
 	},
 	"Default": {
 		RelationName: RELATION_NAME,
@@ -222,194 +233,475 @@ var operationsMap = map[string]Operation{
 	},
 }
 
-var getGenericWorkers = func(node Node) [][]Property {
-	props := make([][]Property, 0)
-	if node[WORKERS] == nil {
-		return props
+// getOperation finds the operation describing a node, the built-in ones first, then the registered ones, and
+// "Default" when the node type is unknown. FORMAT JSON plans name all the aggregates Aggregate, the hashed ones are
+// told apart by their strategy.
+func getOperation(node *PlanNode) Operation {
+	nodeType := node.NodeType
+	if nodeType == AGGREGATE && isHashAggregate(node) {
+		nodeType = HASH_AGGREGATE
 	}
 
-	for _, worker := range node[WORKERS].([]interface{}) {
-		w := worker.(map[string]interface{})
-		work := make([]Property, 0)
+	if operation, ok := operationsMap[nodeType]; ok {
+		return operation
+	}
+	if operation, ok := registeredOperation(node); ok {
+		return operation
+	}
+	return operationsMap["Default"]
+}
 
-		work = append(work, Property{
-			ID:         "worker_number",
-			Name:       "Worker Number",
-			Type:       "float",
-			ValueFloat: w["Worker Number"].(float64),
-		})
-		props = append(props, getGenericWorkerProperties(w, work))
+func isHashAggregate(node *PlanNode) bool {
+	if node.NodeType == HASH_AGGREGATE || node.NodeType == "Partial "+HASH_AGGREGATE {
+		return true
 	}
 
-	return props
+	strategy, _ := node.Extra[STRATEGY].(string)
+	return node.NodeType == AGGREGATE && (strategy == "Hashed" || strategy == "Mixed")
 }
 
-var getSortWorkers = func(node Node) [][]Property {
+// The node specific properties are mostly kept in PlanNode.Extra, the helpers that read them are
+// shared with the workers, thus they take the values rather than the node. Path locates the values
+// in the plan when one of them doesn't have the expected type.
+
+var getGenericWorkers = func(node *PlanNode) ([][]Property, error) {
 	props := make([][]Property, 0)
-	if node[WORKERS] == nil {
-		return props
+
+	for i, w := range node.Workers {
+		path := childPath(node.Path, WORKERS, i)
+		work, err := getWorkerNumberProperty(path, w)
+		if err != nil {
+			return nil, err
+		}
+
+		work, err = getGenericWorkerProperties(path, w, work)
+		if err != nil {
+			return nil, err
+		}
+		props = append(props, work)
 	}
 
-	for _, worker := range node[WORKERS].([]interface{}) {
-		w := worker.(map[string]interface{})
-		work := make([]Property, 0)
-		work = append(work, Property{
-			ID:         "worker_number",
-			Name:       "Worker Number",
-			Type:       "float",
-			ValueFloat: w["Worker Number"].(float64),
-		})
-		props = append(props, getSortProperties(w, getGenericWorkerProperties(w, work)))
+	return props, nil
+}
+
+var getSortWorkers = func(node *PlanNode) ([][]Property, error) {
+	props := make([][]Property, 0)
+
+	for i, w := range node.Workers {
+		path := childPath(node.Path, WORKERS, i)
+		work, err := getWorkerNumberProperty(path, w)
+		if err != nil {
+			return nil, err
+		}
+
+		work, err = getGenericWorkerProperties(path, w, work)
+		if err != nil {
+			return nil, err
+		}
+
+		work, err = getSortProperties(path, w, work)
+		if err != nil {
+			return nil, err
+		}
+		props = append(props, work)
 	}
 
-	return props
+	return props, nil
 }
 
-var getHashWorkers = func(node Node) [][]Property {
+var getMemoizeWorkers = func(node *PlanNode) ([][]Property, error) {
 	workers := make([][]Property, 0)
 
-	if node[WORKERS] == nil {
-		return workers
+	for i, w := range node.Workers {
+		path := childPath(node.Path, WORKERS, i)
+		work, err := getWorkerNumberProperty(path, w)
+		if err != nil {
+			return nil, err
+		}
+
+		work, err = getGenericWorkerProperties(path, w, work)
+		if err != nil {
+			return nil, err
+		}
+
+		work, err = memoizeCacheProperties(path, w, work)
+		if err != nil {
+			return nil, err
+		}
+		workers = append(workers, work)
 	}
 
-	for _, worker := range node[WORKERS].([]interface{}) {
-		w := worker.(map[string]interface{})
-		work := make([]Property, 0)
-		work = append(work, Property{
-			ID:         "worker_number",
-			Name:       "Worker Number",
-			Type:       "float",
-			ValueFloat: w["Worker Number"].(float64),
-		})
+	return workers, nil
+}
+
+var getHashAggregateWorkers = func(node *PlanNode) ([][]Property, error) {
+	workers := make([][]Property, 0)
+
+	for i, w := range node.Workers {
+		path := childPath(node.Path, WORKERS, i)
+		work, err := getWorkerNumberProperty(path, w)
+		if err != nil {
+			return nil, err
+		}
+
+		work, err = getGenericWorkerProperties(path, w, work)
+		if err != nil {
+			return nil, err
+		}
 
-		workers = append(workers, hashBucketsAndBatches(w, getGenericWorkerProperties(w, work)))
+		work, err = hashAggregateProperties(path, w, work)
+		if err != nil {
+			return nil, err
+		}
+		workers = append(workers, work)
 	}
 
-	return workers
+	return workers, nil
 }
 
-func getGenericWorkerProperties(w Node, work []Property) []Property {
-	work = append(work, Property{
-		ID:         "actual_loops",
-		Name:       ACTUAL_LOOPS,
-		Type:       "float",
-		ValueFloat: ConvertToFloat64(w[ACTUAL_LOOPS]),
-		Kind:       Quantity,
-	})
-	work = append(work, Property{
-		ID:         "actual_rows",
-		Name:       ACTUAL_ROWS,
-		Type:       "float",
-		ValueFloat: ConvertToFloat64(w[ACTUAL_ROWS]),
-		Kind:       Quantity,
-	})
+func getWorkerNumberProperty(path string, w Node) ([]Property, error) {
+	workerNumber, err := floatProperty(path, w, "Worker Number")
+	if err != nil {
+		return nil, err
+	}
+
+	work := make([]Property, 0)
 	work = append(work, Property{
-		ID:         "actual_total_time",
-		Name:       ACTUAL_TOTAL_TIME,
+		ID:         "worker_number",
+		Name:       "Worker Number",
 		Type:       "float",
-		ValueFloat: ConvertToFloat64(w[ACTUAL_TOTAL_TIME]),
-		Kind:       Timing,
+		ValueFloat: workerNumber,
 	})
 
-	return work
+	return work, nil
 }
 
-func hashBucketsAndBatches(node Node, props []Property) []Property {
-	if node["Memory Usage"] != nil {
+func getGenericWorkerProperties(path string, w Node, work []Property) ([]Property, error) {
+	for _, property := range []Property{
+		{ID: "actual_loops", Name: ACTUAL_LOOPS, Kind: Quantity},
+		{ID: "actual_rows", Name: ACTUAL_ROWS, Kind: Quantity},
+		{ID: "actual_total_time", Name: ACTUAL_TOTAL_TIME, Kind: Timing},
+	} {
+		value, err := floatProperty(path, w, property.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		property.Type = "float"
+		property.ValueFloat = value
+		work = append(work, property)
+	}
+
+	return work, nil
+}
+
+func getHeapFetchesProperties(node *PlanNode) ([]Property, error) {
+	props := make([]Property, 0)
+
+	if node.Extra[HEAP_FETCHES] != nil {
+		heapFetches, err := floatProperty(node.Path, node.Extra, HEAP_FETCHES)
+		if err != nil {
+			return nil, err
+		}
+
 		props = append(props, Property{
-			ID:         "memory_usage",
-			Name:       "Memory Usage",
+			ID:         "heap_fetches",
+			Name:       "Heap fetches",
 			Type:       "float",
-			ValueFloat: ConvertToFloat64(node["Memory Usage"]),
-			Kind:       DiskSize,
+			ValueFloat: heapFetches,
+			Kind:       Quantity,
 		})
 	}
 
-	if node["Disk Usage"] != nil {
+	return props, nil
+}
+
+// getSortGroupsProperties reads the "Full-sort Groups" or the "Pre-sorted Groups" of an Incremental Sort
+func getSortGroupsProperties(path string, node Node, key string, props []Property) ([]Property, error) {
+	ids := []string{"full_group_count", "average_full_sort_space_used", "peak_full_sort_space_used"}
+	names := []string{"Full Sort Group Count", "Full Average Sort Space Used", "Full Sort Peak Space Used"}
+	if key == PRE_SORTED_GROUPS {
+		ids = []string{"pre_sort_group_count", "average_pre_sort_space_used", "peak_pre_sort_space_used"}
+		names = []string{"Pre Sort Group Count", "Pre Sort Average Space Used", "Pre Sort Peak Space Used"}
+	}
+
+	sortedGroups, err := objectProperty(path, node, key)
+	if err != nil {
+		return nil, err
+	}
+
+	groupCount, err := floatProperty(path, sortedGroups, "Group Count")
+	if err != nil {
+		return nil, err
+	}
+
+	props = append(props, Property{
+		ID:         ids[0],
+		Name:       names[0],
+		Type:       "float",
+		ValueFloat: groupCount,
+		Kind:       Quantity,
+	})
+
+	if sortedGroups["Sort Space Memory"] != nil {
+		sortSpaceMemory, err := objectProperty(path, sortedGroups, "Sort Space Memory")
+		if err != nil {
+			return nil, err
+		}
+
+		averageSpaceUsed, err := floatProperty(path, sortSpaceMemory, "Average Sort Space Used")
+		if err != nil {
+			return nil, err
+		}
+		peakSpaceUsed, err := floatProperty(path, sortSpaceMemory, "Peak Sort Space Used")
+		if err != nil {
+			return nil, err
+		}
+
 		props = append(props, Property{
-			ID:         "disk_usage",
-			Name:       "Disk Usage",
+			ID:         ids[1],
+			Name:       names[1],
 			Type:       "float",
-			ValueFloat: ConvertToFloat64(node["Disk Usage"]),
+			ValueFloat: averageSpaceUsed,
 			Kind:       DiskSize,
 		})
-	}
-
-	if node[BATCHES] != nil {
 		props = append(props, Property{
-			ID:         "batches",
-			Name:       BATCHES,
+			ID:         ids[2],
+			Name:       names[2],
 			Type:       "float",
-			ValueFloat: ConvertToFloat64(node[BATCHES]),
-			Kind:       Quantity,
+			ValueFloat: peakSpaceUsed,
+			Kind:       DiskSize,
 		})
 	}
 
-	if node[BATCHES+" Originally"] != nil {
-		props = append(props, Property{
-			ID:         "batches_originally",
-			Name:       BATCHES + " Originally",
-			Type:       "float",
-			ValueFloat: ConvertToFloat64(node[BATCHES+" Originally"]),
-			Kind:       Quantity,
-		})
+	return props, nil
+}
+
+func hashBucketsAndBatches(path string, node Node, props []Property) ([]Property, error) {
+	for _, property := range []Property{
+		{ID: "memory_usage", Name: "Memory Usage", Kind: DiskSize},
+		{ID: "disk_usage", Name: "Disk Usage", Kind: DiskSize},
+		{ID: "batches", Name: BATCHES, Kind: Quantity},
+		{ID: "batches_originally", Name: BATCHES + " Originally", Kind: Quantity},
+		{ID: "buckets", Name: "Buckets", Kind: Quantity},
+		{ID: "buckets_originally", Name: "Buckets" + " Originally", Kind: Quantity},
+	} {
+		if node[property.Name] == nil {
+			continue
+		}
+
+		value, err := floatProperty(path, node, property.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		property.Type = "float"
+		property.ValueFloat = value
+		props = append(props, property)
 	}
 
-	if node["Buckets"] != nil {
-		props = append(props, Property{
-			ID:         "buckets",
-			Name:       "Buckets",
-			Type:       "float",
-			ValueFloat: ConvertToFloat64(node["Buckets"]),
-			Kind:       Quantity,
-		})
+	return props, nil
+}
+
+// memoizeCacheProperties reads the cache counters of a Memoize node, or of one of its workers
+func memoizeCacheProperties(path string, node Node, props []Property) ([]Property, error) {
+	for _, property := range []Property{
+		{ID: "cache_hits", Name: CACHE_HITS, Kind: Quantity},
+		{ID: "cache_misses", Name: CACHE_MISSES, Kind: Quantity},
+		{ID: "cache_evictions", Name: CACHE_EVICTIONS, Kind: Quantity},
+		{ID: "cache_overflows", Name: CACHE_OVERFLOWS, Kind: Quantity},
+		{ID: "peak_memory_usage", Name: PEAK_MEMORY_USAGE, Kind: DiskSize},
+	} {
+		if node[property.Name] == nil {
+			continue
+		}
+
+		value, err := floatProperty(path, node, property.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		property.Type = "float"
+		property.ValueFloat = value
+		props = append(props, property)
+	}
+
+	if node[CACHE_HITS] == nil || node[CACHE_MISSES] == nil {
+		return props, nil
 	}
 
-	if node["Buckets"+" Originally"] != nil {
+	// Both have been checked above
+	hits, _ := floatProperty(path, node, CACHE_HITS)
+	misses, _ := floatProperty(path, node, CACHE_MISSES)
+	if hits+misses > 0 {
 		props = append(props, Property{
-			ID:         "buckets_originally",
-			Name:       "Buckets" + " Originally",
+			ID:         "cache_hit_ratio",
+			Name:       "Cache Hit Ratio",
 			Type:       "float",
-			ValueFloat: ConvertToFloat64(node["Buckets Originally"]),
-			Kind:       Quantity,
+			ValueFloat: memoizeHitRatio(hits, misses),
+			Kind:       Percentage,
 		})
 	}
 
-	return props
+	return props, nil
+}
+
+func memoizeHitRatio(hits float64, misses float64) float64 {
+	return hits / (hits + misses) * 100
 }
 
-func getSortProperties(node Node, props []Property) []Property {
+func getMemoizeInsights(node *PlanNode) ([]string, []string, error) {
+	var warnings, infos []string
+
+	counters := map[string]float64{}
+	for _, key := range []string{CACHE_HITS, CACHE_MISSES, CACHE_EVICTIONS, CACHE_OVERFLOWS} {
+		if node.Extra[key] == nil {
+			continue
+		}
+
+		value, err := floatProperty(node.Path, node.Extra, key)
+		if err != nil {
+			return nil, nil, err
+		}
+		counters[key] = value
+	}
+
+	hits, misses := counters[CACHE_HITS], counters[CACHE_MISSES]
+	if hits+misses == 0 {
+		return nil, nil, nil
+	}
+
+	ratio := memoizeHitRatio(hits, misses)
+	if ratio < memoizeLowHitRatio {
+		warnings = append(warnings, fmt.Sprintf("Memoize cache hit ratio is %.1f%%, most lookups run the inner side of the join, the cache key has many distinct values", ratio))
+	} else {
+		infos = append(infos, fmt.Sprintf("Memoize cache hit ratio is %.1f%%", ratio))
+	}
+
+	if counters[CACHE_EVICTIONS] > 0 {
+		warnings = append(warnings, fmt.Sprintf("Memoize evicted %v cache entries, the cache doesn't fit in work_mem * hash_mem_multiplier", counters[CACHE_EVICTIONS]))
+	}
+	if counters[CACHE_OVERFLOWS] > 0 {
+		warnings = append(warnings, fmt.Sprintf("Memoize cache overflowed %v times, some lookups returned more rows than the cache can hold", counters[CACHE_OVERFLOWS]))
+	}
+
+	return warnings, infos, nil
+}
+
+// getBitmapHeapScanInsights flags the lossy bitmaps, when the bitmap doesn't fit in work_mem it only keeps the
+// pages of some heap blocks, thus all their rows have to be rechecked against the condition
+func getBitmapHeapScanInsights(node *PlanNode) ([]string, []string, error) {
+	if node.Extra[LOSSY_HEAP_BLOCKS] == nil {
+		return nil, nil, nil
+	}
+
+	lossy, err := floatProperty(node.Path, node.Extra, LOSSY_HEAP_BLOCKS)
+	if err != nil || lossy == 0 {
+		return nil, nil, err
+	}
+
+	exact := 0.0
+	if node.Extra[EXACT_HEAP_BLOCKS] != nil {
+		if exact, err = floatProperty(node.Path, node.Extra, EXACT_HEAP_BLOCKS); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	warning := fmt.Sprintf("Bitmap is lossy for %v of %v heap blocks, increase work_mem to keep an exact bitmap", lossy, lossy+exact)
+	if node.RowsRemovedByRecheckRevised > 0 {
+		warning += fmt.Sprintf(", %v rows were removed by the recheck", node.RowsRemovedByRecheckRevised)
+	}
+
+	return []string{warning}, nil, nil
+}
+
+func getHashAggregateProperties(node *PlanNode) ([]Property, error) {
+	props := make([]Property, 0)
+	return hashAggregateProperties(node.Path, node.Extra, props)
+}
+
+// hashAggregateProperties reads the memory and the disk used by a hash aggregation (PG13+), of the leader or
+// of one of the workers
+func hashAggregateProperties(path string, node Node, props []Property) ([]Property, error) {
+	for _, property := range []Property{
+		{ID: "planned_partitions", Name: PLANNED_PARTITIONS, Kind: Quantity},
+		{ID: "hashagg_batches", Name: HASH_AGG_BATCHES, Kind: Quantity},
+		{ID: "peak_memory_usage", Name: PEAK_MEMORY_USAGE, Kind: DiskSize},
+		{ID: "disk_usage", Name: DISK_USAGE, Kind: DiskSize},
+	} {
+		if node[property.Name] == nil {
+			continue
+		}
+
+		value, err := floatProperty(path, node, property.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		property.Type = "float"
+		property.ValueFloat = value
+		props = append(props, property)
+	}
+
+	return props, nil
+}
+
+func getHashAggregateInsights(node *PlanNode) ([]string, []string, error) {
+	aggregate, err := newHashAggregateStats(node)
+	if err != nil || aggregate == nil || !aggregate.Spilled {
+		return nil, nil, err
+	}
+
+	return []string{
+		fmt.Sprintf("Hash aggregate spilled to disk in %v batches, %v kB written, increase work_mem or hash_mem_multiplier", aggregate.Batches, aggregate.DiskUsage),
+	}, nil, nil
+}
+
+func getSortProperties(path string, node Node, props []Property) ([]Property, error) {
 	if node[SORT_METHOD] != nil {
+		sortMethod, err := stringProperty(path, node, SORT_METHOD)
+		if err != nil {
+			return nil, err
+		}
+
 		props = append(props, Property{
 			ID:          "sort_method",
 			Name:        "Sort method",
 			Type:        "string",
-			ValueString: node[SORT_METHOD].(string),
+			ValueString: sortMethod,
 		})
 	}
 
 	if node[SORT_SPACE_TYPE] != nil {
-		property := Property{
+		sortSpaceType, err := stringProperty(path, node, SORT_SPACE_TYPE)
+		if err != nil {
+			return nil, err
+		}
+
+		props = append(props, Property{
 			ID:          "sort_space_type",
 			Name:        SORT_SPACE_TYPE,
 			Type:        "string",
-			ValueString: node[SORT_SPACE_TYPE].(string),
-		}
-		props = append(props, property)
+			ValueString: sortSpaceType,
+		})
 	}
 
 	if node[SORT_SPACE_USED] != nil {
-		property := Property{
+		sortSpaceUsed, err := floatProperty(path, node, SORT_SPACE_USED)
+		if err != nil {
+			return nil, err
+		}
+
+		props = append(props, Property{
 			ID:         "sort_space_used",
 			Name:       SORT_SPACE_USED,
 			Type:       "float",
-			ValueFloat: ConvertToFloat64(node[SORT_SPACE_USED]),
+			ValueFloat: sortSpaceUsed,
 			Kind:       DiskSize,
-		}
-		props = append(props, property)
+		})
 	}
 
-	return props
+	return props, nil
 }
 
 var filtersMap = map[string]string{
@@ -419,8 +711,9 @@ var filtersMap = map[string]string{
 }
 
 const (
-	Timing   = Kind("timing")
-	Quantity = Kind("quantity")
-	DiskSize = Kind("disk_size")
-	Blocks   = Kind("blocks")
+	Timing     = Kind("timing")
+	Quantity   = Kind("quantity")
+	DiskSize   = Kind("disk_size")
+	Blocks     = Kind("blocks")
+	Percentage = Kind("percentage")
 )
@@ -0,0 +1,194 @@
+package pkg
+
+// The tuples counters of a ModifyTable, the ones of MERGE are only reported by PG15+
+var modifyTableTuples = []Property{
+	{ID: "tuples_inserted", Name: TUPLES_INSERTED, Kind: Quantity},
+	{ID: "conflicting_tuples", Name: CONFLICTING_TUPLES, Kind: Quantity},
+	{ID: "tuples_updated", Name: TUPLES_UPDATED, Kind: Quantity},
+	{ID: "tuples_deleted", Name: TUPLES_DELETED, Kind: Quantity},
+	{ID: "tuples_skipped", Name: TUPLES_SKIPPED, Kind: Quantity},
+}
+
+func getModifyTableProperties(node *PlanNode) ([]Property, error) {
+	props := make([]Property, 0)
+
+	for _, property := range []Property{
+		{ID: "operation", Name: DML_OPERATION},
+		{ID: "conflict_resolution", Name: CONFLICT_RESOLUTION},
+	} {
+		if node.Extra[property.Name] == nil {
+			continue
+		}
+
+		value, err := stringProperty(node.Path, node.Extra, property.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		property.Type = "string"
+		property.ValueString = value
+		props = append(props, property)
+	}
+
+	for _, property := range modifyTableTuples {
+		if node.Extra[property.Name] == nil {
+			continue
+		}
+
+		value, err := floatProperty(node.Path, node.Extra, property.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		property.Type = "float"
+		property.ValueFloat = value
+		props = append(props, property)
+	}
+
+	return props, nil
+}
+
+// ComputeDMLStats describes the ModifyTable nodes of the plan, nil when there are none. The time of the triggers
+// is attributed to the statement modifying the relation they're defined on, the WAL is the one of the whole query.
+func (s *StatsGather) ComputeDMLStats(node *PlanNode) (*DMLStats, error) {
+	nodes := modifyTableNodes(node, make([]*PlanNode, 0))
+	if len(nodes) == 0 {
+		return nil, nil
+	}
+
+	stats := &DMLStats{Statements: make([]DMLStatement, 0, len(nodes))}
+	for _, modifyTable := range nodes {
+		statement, err := newDMLStatement(modifyTable)
+		if err != nil {
+			return nil, err
+		}
+		stats.Statements = append(stats.Statements, statement)
+		stats.TotalTime += statement.Time
+	}
+
+	triggers, err := s.ComputeTriggersStats()
+	if err != nil {
+		return nil, err
+	}
+
+	if triggers != nil {
+		for _, trigger := range triggers.Items {
+			stats.TriggersTime += trigger.Time
+			if i, ok := triggerStatement(trigger, nodes); ok {
+				stats.Statements[i].TriggersTime += trigger.Time
+			}
+		}
+	}
+	stats.TotalTime += stats.TriggersTime
+
+	if node.WAL != nil {
+		stats.WALRecords = node.WAL.Records
+		stats.WALBytes = node.WAL.Bytes
+		stats.WALFPI = node.WAL.FPI
+	}
+
+	return stats, nil
+}
+
+// triggerStatement finds the ModifyTable of the relation a trigger is defined on. The relation of the triggers is
+// reported without its schema, and the TEXT format only reports it when the triggers are on several relations, the
+// triggers that can't be tied to a single relation are left to the query.
+func triggerStatement(trigger Trigger, nodes []*PlanNode) (int, bool) {
+	found := -1
+	for i, node := range nodes {
+		if trigger.Relation == "" || trigger.Relation != node.RelationName {
+			continue
+		}
+		if trigger.Schema != "" && node.Schema != "" && trigger.Schema != node.Schema {
+			continue
+		}
+
+		if found < 0 {
+			found = i
+		} else if nodes[found].Schema != node.Schema {
+			// The same name in two schemas
+			return 0, false
+		}
+	}
+	return found, found >= 0
+}
+
+// modifyTableNodes also walks the CTEs, the data modifying ones hold their own ModifyTable, and the Citus tasks
+// modifying the shards
+func modifyTableNodes(node *PlanNode, nodes []*PlanNode) []*PlanNode {
+	if node.NodeType == MODIFY_TABLE {
+		nodes = append(nodes, node)
+	}
+
+	for _, subNode := range node.subNodes() {
+		nodes = modifyTableNodes(subNode, nodes)
+	}
+
+	return nodes
+}
+
+func newDMLStatement(node *PlanNode) (DMLStatement, error) {
+	statement := DMLStatement{
+		Id:    node.NodeId,
+		Table: qualifiedName(node.Schema, node.RelationName),
+		Alias: node.Alias,
+		Time:  node.InclusiveDuration,
+	}
+
+	for _, property := range []struct {
+		value *string
+		key   string
+	}{
+		{&statement.Command, DML_OPERATION},
+		{&statement.ConflictResolution, CONFLICT_RESOLUTION},
+	} {
+		if node.Extra[property.key] == nil {
+			continue
+		}
+
+		value, err := stringProperty(node.Path, node.Extra, property.key)
+		if err != nil {
+			return DMLStatement{}, err
+		}
+		*property.value = value
+	}
+
+	if node.Extra[CONFLICT_ARBITER_INDEXES] != nil {
+		arbiterIndexes, err := stringsProperty(node.Path, node.Extra, CONFLICT_ARBITER_INDEXES)
+		if err != nil {
+			return DMLStatement{}, err
+		}
+		statement.ArbiterIndexes = arbiterIndexes
+	}
+
+	tuples := map[string]float64{}
+	for _, property := range modifyTableTuples {
+		if node.Extra[property.Name] == nil {
+			continue
+		}
+
+		value, err := floatProperty(node.Path, node.Extra, property.Name)
+		if err != nil {
+			return DMLStatement{}, err
+		}
+		tuples[property.Name] = value
+	}
+	statement.TuplesInserted = tuples[TUPLES_INSERTED]
+	statement.ConflictingTuples = tuples[CONFLICTING_TUPLES]
+	statement.TuplesUpdated = tuples[TUPLES_UPDATED]
+	statement.TuplesDeleted = tuples[TUPLES_DELETED]
+	statement.TuplesSkipped = tuples[TUPLES_SKIPPED]
+
+	if node.WAL != nil {
+		statement.WAL = WAL{
+			Records:          node.WAL.Records,
+			Bytes:            node.WAL.Bytes,
+			FPI:              node.WAL.FPI,
+			ExclusiveRecords: node.ExclusiveWAL.Records,
+			ExclusiveBytes:   node.ExclusiveWAL.Bytes,
+			ExclusiveFPI:     node.ExclusiveWAL.FPI,
+		}
+	}
+
+	return statement, nil
+}
@@ -0,0 +1,100 @@
+package pkg
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExplain_DML(t *testing.T) {
+	tests := []struct {
+		name      string
+		plan      string
+		wantScope NodeScopes
+		want      *DMLStats
+	}{
+		{
+			name: "insert on conflict",
+			plan: `[{"Plan":{"Node Type":"ModifyTable","Operation":"Insert","Relation Name":"orders","Schema":"public","Alias":"orders","Total Cost":0.01,"Plan Rows":0,"Actual Startup Time":0.5,"Actual Total Time":0.5,"Actual Rows":0,"Actual Loops":1,` +
+				`"Conflict Resolution":"UPDATE","Conflict Arbiter Indexes":["orders_pkey"],"Conflict Filter":"(orders.total > 0)","Tuples Inserted":2,"Conflicting Tuples":1,"WAL Records":4,"WAL Bytes":400,"WAL FPI":1,"Plans":[` +
+				`{"Node Type":"Values Scan","Parent Relationship":"Outer","Alias":"*VALUES*","Total Cost":0.01,"Plan Rows":3,"Actual Startup Time":0,"Actual Total Time":0.01,"Actual Rows":3,"Actual Loops":1,"WAL Records":0,"WAL Bytes":0,"WAL FPI":0}]},` +
+				`"Triggers":[{"Trigger Name":"orders_audit","Relation":"orders","Time":0.2,"Calls":3},{"Trigger Name":"RI_ConstraintTrigger_c_1","Time":0.1,"Calls":3}],"Execution Time":1}]`,
+			wantScope: NodeScopes{Table: "orders", Schema: "public", Alias: "orders", Command: "Insert", Filters: "(orders.total > 0)", Index: "[\n    \"orders_pkey\"\n]"},
+			want: &DMLStats{
+				Statements: []DMLStatement{{
+					Command:            "Insert",
+					Table:              "public.orders",
+					Alias:              "orders",
+					ConflictResolution: "UPDATE",
+					ArbiterIndexes:     []string{"orders_pkey"},
+					TuplesInserted:     2,
+					ConflictingTuples:  1,
+					Time:               0.5,
+					TriggersTime:       0.2,
+					WAL:                WAL{Records: 4, Bytes: 400, FPI: 1, ExclusiveRecords: 4, ExclusiveBytes: 400, ExclusiveFPI: 1},
+				}},
+				TriggersTime: 0.30000000000000004,
+				TotalTime:    0.8,
+				WALRecords:   4,
+				WALBytes:     400,
+				WALFPI:       1,
+			},
+		},
+		{
+			name:      "select",
+			plan:      `[{"Plan":{"Node Type":"Seq Scan","Relation Name":"orders","Alias":"orders","Total Cost":10,"Plan Rows":10}}]`,
+			wantScope: NodeScopes{Table: "orders", Alias: "orders"},
+			want:      nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			explained, err := Explain(tt.plan, ExplainOptions{IDMode: IDModeDeterministic})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := explained.Summary[0].Scopes; got != tt.wantScope {
+				t.Errorf("scopes = %+v, want %+v", got, tt.wantScope)
+			}
+
+			got := explained.DML
+			if got != nil {
+				for i := range got.Statements {
+					got.Statements[i].Id = ""
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DML = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTriggerStatement(t *testing.T) {
+	nodes := []*PlanNode{
+		{NodeType: MODIFY_TABLE, RelationName: "orders", Schema: "public"},
+		{NodeType: MODIFY_TABLE, RelationName: "orders", Schema: "archive"},
+		{NodeType: MODIFY_TABLE, RelationName: "customers"},
+	}
+
+	tests := []struct {
+		name    string
+		trigger Trigger
+		want    int
+		wantOk  bool
+	}{
+		{name: "single relation", trigger: Trigger{Relation: "customers"}, want: 2, wantOk: true},
+		{name: "same name in two schemas", trigger: Trigger{Relation: "orders"}, wantOk: false},
+		{name: "schema", trigger: Trigger{Relation: "orders", Schema: "archive"}, want: 1, wantOk: true},
+		{name: "other relation", trigger: Trigger{Relation: "invoices"}, wantOk: false},
+		{name: "without relation", trigger: Trigger{}, wantOk: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := triggerStatement(tt.trigger, nodes)
+			if ok != tt.wantOk || (ok && got != tt.want) {
+				t.Errorf("triggerStatement() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+)
+
+// PlanError locates a property of the plan that doesn't have the expected type. Path is the position of the
+// node in the tree, e.g. Plans[0].Plans[2] or Plans[1].Workers[0], empty for the root node. It is also used
+// for the query level properties, e.g. Triggers[0].
+type PlanError struct {
+	Path     string
+	Key      string
+	Expected string
+	Got      string
+}
+
+func newPlanError(path string, key string, expected string, value interface{}) *PlanError {
+	return &PlanError{
+		Path:     path,
+		Key:      key,
+		Expected: expected,
+		Got:      describeValue(value),
+	}
+}
+
+func (e *PlanError) Error() string {
+	path := e.Path
+	if path == "" {
+		path = "Plan"
+	}
+
+	if e.Key == "" {
+		return fmt.Sprintf("%v: expected %v, got %v", path, e.Expected, e.Got)
+	}
+	return fmt.Sprintf("%v: %q expected %v, got %v", path, e.Key, e.Expected, e.Got)
+}
+
+// NewExplainedError converts any error returned by the package into the form sent to the clients,
+// the details locate the malformed property and the stack is only known for recovered panics
+func NewExplainedError(err error) ExplainedError {
+	explainedError := ExplainedError{
+		Error:   err.Error(),
+		Details: err.Error(),
+	}
+	if unwrapped := errors.Unwrap(err); unwrapped != nil {
+		explainedError.Details = unwrapped.Error()
+	}
+
+	var planErr *PlanError
+	if errors.As(err, &planErr) {
+		explainedError.Details = fmt.Sprintf("path: %v, key: %v, expected: %v, got: %v", planErr.Path, planErr.Key, planErr.Expected, planErr.Got)
+	}
+
+	var explainErr *ExplainError
+	if errors.As(err, &explainErr) {
+		explainedError.Stack = explainErr.Stack
+	}
+
+	return explainedError
+}
+
+func childPath(path string, key string, i int) string {
+	if path == "" {
+		return fmt.Sprintf("%v[%d]", key, i)
+	}
+	return fmt.Sprintf("%v.%v[%d]", path, key, i)
+}
+
+func propertyPath(path string, key string) string {
+	if path == "" {
+		return key
+	}
+	return fmt.Sprintf("%v.%v", path, key)
+}
+
+// describeValue names the JSON type of a decoded value, scalars are followed by the value itself
+func describeValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		if len(v) > 32 {
+			v = v[:32] + "..."
+		}
+		return fmt.Sprintf("string %q", v)
+	case float64:
+		return fmt.Sprintf("number %v", v)
+	case bool:
+		return fmt.Sprintf("boolean %v", v)
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+func floatProperty(path string, values Node, key string) (float64, error) {
+	value, err := ConvertToFloat64(values[key])
+	if err != nil {
+		return 0.0, newPlanError(path, key, "number", values[key])
+	}
+	return value, nil
+}
+
+func optionalFloatProperty(path string, values Node, key string) (*float64, error) {
+	if values[key] == nil {
+		return nil, nil
+	}
+
+	value, err := floatProperty(path, values, key)
+	if err != nil {
+		return nil, err
+	}
+	return &value, nil
+}
+
+func boolProperty(path string, values Node, key string) (bool, error) {
+	value, ok := values[key].(bool)
+	if !ok {
+		return false, newPlanError(path, key, "boolean", values[key])
+	}
+	return value, nil
+}
+
+func stringProperty(path string, values Node, key string) (string, error) {
+	value, ok := values[key].(string)
+	if !ok {
+		return "", newPlanError(path, key, "string", values[key])
+	}
+	return value, nil
+}
+
+func stringsProperty(path string, values Node, key string) ([]string, error) {
+	list, ok := values[key].([]interface{})
+	if !ok {
+		return nil, newPlanError(path, key, "array of strings", values[key])
+	}
+
+	items := make([]string, 0, len(list))
+	for _, item := range list {
+		value, ok := item.(string)
+		if !ok {
+			return nil, newPlanError(path, key, "array of strings", values[key])
+		}
+		items = append(items, value)
+	}
+	return items, nil
+}
+
+func objectProperty(path string, values Node, key string) (Node, error) {
+	value, ok := values[key].(map[string]interface{})
+	if !ok {
+		return nil, newPlanError(path, key, "object", values[key])
+	}
+	return value, nil
+}
+
+// scopeProperty takes the value rather than the values, the scopes are read with PlanNode.Get
+func scopeProperty(path string, key string, value interface{}) (string, error) {
+	scope, err := ConvertScopeToString(value)
+	if err != nil {
+		return "", newPlanError(path, key, "string or array", value)
+	}
+	return scope, nil
+}
@@ -0,0 +1,99 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestExplain_Errors(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		wantStage   string
+		wantErr     *PlanError
+		wantDetails string
+	}{
+		{
+			name:        "malformed node",
+			input:       `[{"Plan":{"Node Type":"Result","Plans":[{"Node Type":"Seq Scan","Plans":[{"Node Type":"Seq Scan"},{"Node Type":"Seq Scan","Actual Rows":"many"}]}]}}]`,
+			wantStage:   StageParse,
+			wantErr:     &PlanError{Path: "Plans[0].Plans[1]", Key: ACTUAL_ROWS, Expected: "number", Got: `string "many"`},
+			wantDetails: `path: Plans[0].Plans[1], key: Actual Rows, expected: number, got: string "many"`,
+		},
+		{
+			name:      "malformed worker",
+			input:     `[{"Plan":{"Node Type":"Gather","Plans":[{"Node Type":"Seq Scan","Workers":[{"Worker Number":0,"Actual Rows":true}]}]}}]`,
+			wantStage: StageSummary,
+			wantErr:   &PlanError{Path: "Plans[0].Workers[0]", Key: ACTUAL_ROWS, Expected: "number", Got: "boolean true"},
+		},
+		{
+			name:      "malformed trigger",
+			input:     `[{"Plan":{"Node Type":"Result"},"Triggers":[{"Trigger Name":"audit","Time":0.1,"Calls":"x"}]}]`,
+			wantStage: StageStats,
+			wantErr:   &PlanError{Path: "Triggers[0]", Key: "Calls", Expected: "number", Got: `string "x"`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Explain(tt.input, ExplainOptions{})
+
+			var explainErr *ExplainError
+			if !errors.As(err, &explainErr) {
+				t.Fatalf("Explain() error = %v, want *ExplainError", err)
+			}
+			if explainErr.Stage != tt.wantStage {
+				t.Errorf("Explain() error stage = %v, want %v", explainErr.Stage, tt.wantStage)
+			}
+
+			var planErr *PlanError
+			if !errors.As(err, &planErr) {
+				t.Fatalf("Explain() error = %v, want *PlanError", err)
+			}
+			if *planErr != *tt.wantErr {
+				t.Errorf("Explain() error = %+v, want %+v", planErr, tt.wantErr)
+			}
+
+			explainedError := NewExplainedError(err)
+			if tt.wantDetails != "" && explainedError.Details != tt.wantDetails {
+				t.Errorf("NewExplainedError() details = %v, want %v", explainedError.Details, tt.wantDetails)
+			}
+			if explainedError.Error != err.Error() || explainedError.Stack != "" {
+				t.Errorf("NewExplainedError() = %+v", explainedError)
+			}
+		})
+	}
+}
+
+func TestNewExplainedError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want ExplainedError
+	}{
+		{
+			name: "recovered panic",
+			err:  &ExplainError{Stage: StageEnrich, Err: fmt.Errorf("unexpected failure: boom"), Stack: "goroutine 1"},
+			want: ExplainedError{
+				Error:   "explain enrich: unexpected failure: boom",
+				Details: "unexpected failure: boom",
+				Stack:   "goroutine 1",
+			},
+		},
+		{
+			name: "plain error",
+			err:  ErrEmptyPlans,
+			want: ExplainedError{
+				Error:   ErrEmptyPlans.Error(),
+				Details: ErrEmptyPlans.Error(),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewExplainedError(tt.err); got != tt.want {
+				t.Errorf("NewExplainedError() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package pkg
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+const (
+	StageParse   = "parse"
+	StageEnrich  = "enrich"
+	StageStats   = "stats"
+	StageSummary = "summary"
+)
+
+// ExplainOptions toggles the optional parts of the pipeline, the zero value computes everything
+type ExplainOptions struct {
+	DisableIndexesStats     bool
+	DisableTablesStats      bool
+	DisableNodesStats       bool
+	DisableJITStats         bool
+	DisableTriggersStats    bool
+	DisableOutputStats      bool
+	DisableSpillStats       bool
+	DisablePartitionsStats  bool
+	DisableDMLStats         bool
+	DisableDistributedStats bool
+	DisableHypertablesStats bool
+
+	// IDMode chooses how the node identifiers are generated, the zero value is IDModeRandom
+	IDMode IDMode
+}
+
+// ExplainError is returned by Explain, Stage tells which step of the pipeline failed. Stack is only set when
+// the error comes from a recovered panic. Use NewExplainedError to send it to the clients.
+type ExplainError struct {
+	Stage string
+	Err   error
+	Stack string
+}
+
+func (e *ExplainError) Error() string {
+	return fmt.Sprintf("explain %v: %v", e.Stage, e.Err)
+}
+
+func (e *ExplainError) Unwrap() error {
+	return e.Err
+}
+
+// pipeline remembers the stage being run, so that a recovered panic can be reported with it
+type pipeline struct {
+	opts  ExplainOptions
+	stage string
+}
+
+// Explain runs the whole pipeline over a plan in any of the EXPLAIN formats: parsing, enrichment, stats and summary.
+// Only the first query is explained, use ExplainAll for plans containing several queries.
+func Explain(input string, opts ExplainOptions) (Explained, error) {
+	explained, err := ExplainAll(input, opts)
+	if err != nil {
+		return Explained{}, err
+	}
+
+	return explained[0], nil
+}
+
+// ExplainAll returns one Explained for every query in the plan, each one with its own stats, triggers and JIT.
+// It never panics, an unexpected failure is returned as an *ExplainError carrying the stack.
+func ExplainAll(input string, opts ExplainOptions) (explained []Explained, err error) {
+	p := &pipeline{opts: opts, stage: StageParse}
+
+	defer func() {
+		if r := recover(); r != nil {
+			explained = nil
+			err = &ExplainError{Stage: p.stage, Err: fmt.Errorf("unexpected failure: %v", r), Stack: string(debug.Stack())}
+		}
+	}()
+
+	return p.run(input)
+}
+
+func (p *pipeline) run(input string) ([]Explained, error) {
+	input, cleanup, err := ConvertPlanToJSONWithReport(input)
+	if err != nil {
+		return nil, &ExplainError{Stage: StageParse, Err: err}
+	}
+
+	rootNodes, err := GetRootNodesFromPlans(input)
+	if err != nil {
+		return nil, &ExplainError{Stage: StageParse, Err: err}
+	}
+
+	p.stage = StageStats
+	stats, err := getStatsFromPlans(input)
+	if err != nil {
+		return nil, &ExplainError{Stage: StageStats, Err: err}
+	}
+
+	explained := make([]Explained, 0, len(rootNodes))
+	for i, rootNode := range rootNodes {
+		e, err := p.explainPlan(rootNode, stats[i])
+		if err != nil {
+			if len(rootNodes) > 1 {
+				err.Err = fmt.Errorf("query %v: %w", i, err.Err)
+			}
+			return nil, err
+		}
+		e.Cleanup = cleanup
+		explained = append(explained, e)
+	}
+
+	return explained, nil
+}
+
+// explainPlan runs the pipeline over a single query. The order matters, stats and summary read the values
+// computed by the PlanEnricher.
+func (p *pipeline) explainPlan(rootNode *PlanNode, stats StatsFromPlan) (Explained, *ExplainError) {
+	p.stage = StageEnrich
+	enricher := NewPlanEnricher()
+	if p.opts.IDMode != "" {
+		enricher.SetIDMode(p.opts.IDMode)
+	}
+	enricher.AnalyzePlan(rootNode)
+
+	p.stage = StageStats
+	statsGather := NewStatsGather()
+	statsGather.GetStatsFromPlan(stats)
+
+	explained := Explained{
+		Stats: statsGather.ComputeStats(rootNode),
+	}
+
+	if !p.opts.DisableIndexesStats {
+		explained.IndexesStats = statsGather.ComputeIndexesStats(rootNode)
+	}
+	if !p.opts.DisableTablesStats {
+		explained.TablesStats = statsGather.ComputeTablesStats(rootNode)
+	}
+	if !p.opts.DisableNodesStats {
+		explained.NodesStats = statsGather.ComputeNodesStats(rootNode)
+	}
+	if !p.opts.DisableJITStats {
+		explained.JITStats = statsGather.ComputeJITStats()
+	}
+	if !p.opts.DisableTriggersStats {
+		triggers, err := statsGather.ComputeTriggersStats()
+		if err != nil {
+			return Explained{}, &ExplainError{Stage: StageStats, Err: err}
+		}
+		explained.TriggersStats = triggers
+	}
+	if !p.opts.DisableOutputStats {
+		explained.OutputStats = statsGather.ComputeOutputStats(rootNode)
+	}
+	if !p.opts.DisableSpillStats {
+		spill, err := statsGather.ComputeSpillStats(rootNode)
+		if err != nil {
+			return Explained{}, &ExplainError{Stage: StageStats, Err: err}
+		}
+		explained.SpillStats = spill
+	}
+	if !p.opts.DisablePartitionsStats {
+		partitions, err := statsGather.ComputePartitionsStats(rootNode)
+		if err != nil {
+			return Explained{}, &ExplainError{Stage: StageStats, Err: err}
+		}
+		explained.PartitionsStats = partitions
+	}
+	if !p.opts.DisableDMLStats {
+		dml, err := statsGather.ComputeDMLStats(rootNode)
+		if err != nil {
+			return Explained{}, &ExplainError{Stage: StageStats, Err: err}
+		}
+		explained.DML = dml
+	}
+	if !p.opts.DisableDistributedStats {
+		distributed, err := statsGather.ComputeDistributedStats(rootNode)
+		if err != nil {
+			return Explained{}, &ExplainError{Stage: StageStats, Err: err}
+		}
+		explained.DistributedStats = distributed
+	}
+	if !p.opts.DisableHypertablesStats {
+		hypertables, err := statsGather.ComputeHypertablesStats(rootNode)
+		if err != nil {
+			return Explained{}, &ExplainError{Stage: StageStats, Err: err}
+		}
+		explained.HypertablesStats = hypertables
+	}
+
+	p.stage = StageSummary
+	summary, err := NewSummary().Do(rootNode, explained.Stats)
+	if err != nil {
+		return Explained{}, &ExplainError{Stage: StageSummary, Err: err}
+	}
+	explained.Summary = summary
+
+	return explained, nil
+}
@@ -0,0 +1,431 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+)
+
+const explainTestPlan = `[{"Plan":{"Node Type":"Hash Join","Parallel Aware":false,"Join Type":"Inner","Startup Cost":1.04,"Total Cost":2.13,"Plan Rows":3,"Plan Width":8,"Actual Startup Time":0.025,"Actual Total Time":0.03,"Actual Rows":3,"Actual Loops":1,"Hash Cond":"(a.id = b.id)","Shared Hit Blocks":2,"Shared Read Blocks":0,"Shared Dirtied Blocks":0,"Shared Written Blocks":0,"Local Hit Blocks":0,"Local Read Blocks":0,"Local Dirtied Blocks":0,"Local Written Blocks":0,"Temp Read Blocks":0,"Temp Written Blocks":0,"Plans":[{"Node Type":"Seq Scan","Parent Relationship":"Outer","Parallel Aware":false,"Relation Name":"a","Alias":"a","Startup Cost":0,"Total Cost":1.03,"Plan Rows":3,"Plan Width":4,"Actual Startup Time":0.005,"Actual Total Time":0.006,"Actual Rows":3,"Actual Loops":1,"Shared Hit Blocks":1,"Shared Read Blocks":0,"Shared Dirtied Blocks":0,"Shared Written Blocks":0,"Local Hit Blocks":0,"Local Read Blocks":0,"Local Dirtied Blocks":0,"Local Written Blocks":0,"Temp Read Blocks":0,"Temp Written Blocks":0},{"Node Type":"Hash","Parent Relationship":"Inner","Parallel Aware":false,"Startup Cost":1.02,"Total Cost":1.02,"Plan Rows":2,"Plan Width":4,"Actual Startup Time":0.009,"Actual Total Time":0.009,"Actual Rows":2,"Actual Loops":1,"Hash Buckets":1024,"Original Hash Buckets":1024,"Hash Batches":1,"Original Hash Batches":1,"Peak Memory Usage":9,"Shared Hit Blocks":1,"Shared Read Blocks":0,"Shared Dirtied Blocks":0,"Shared Written Blocks":0,"Local Hit Blocks":0,"Local Read Blocks":0,"Local Dirtied Blocks":0,"Local Written Blocks":0,"Temp Read Blocks":0,"Temp Written Blocks":0,"Plans":[{"Node Type":"Index Scan","Parent Relationship":"Outer","Parallel Aware":false,"Scan Direction":"Forward","Index Name":"b_pkey","Relation Name":"b","Alias":"b","Startup Cost":0.13,"Total Cost":1.02,"Plan Rows":2,"Plan Width":4,"Actual Startup Time":0.004,"Actual Total Time":0.005,"Actual Rows":2,"Actual Loops":1,"Index Cond":"(id > 0)","Rows Removed by Index Recheck":0,"Shared Hit Blocks":1,"Shared Read Blocks":0,"Shared Dirtied Blocks":0,"Shared Written Blocks":0,"Local Hit Blocks":0,"Local Read Blocks":0,"Local Dirtied Blocks":0,"Local Written Blocks":0,"Temp Read Blocks":0,"Temp Written Blocks":0}]}]},"Planning Time":0.1,"Triggers":[{"Trigger Name":"audit","Relation":"a","Time":0.02,"Calls":"3"}],"Execution Time":0.05}]`
+
+func TestExplain(t *testing.T) {
+	type args struct {
+		input string
+		opts  ExplainOptions
+	}
+	tests := []struct {
+		name          string
+		args          args
+		wantRows      int
+		wantTables    int
+		wantIndexes   int
+		wantTriggers  bool
+		wantErrStage  string
+		wantExecution float64
+	}{
+		{
+			name: "full pipeline",
+			args: args{
+				input: explainTestPlan,
+			},
+			wantRows:      4,
+			wantTables:    2,
+			wantIndexes:   1,
+			wantTriggers:  true,
+			wantExecution: 0.05,
+		},
+		{
+			name: "optional stats disabled",
+			args: args{
+				input: explainTestPlan,
+				opts: ExplainOptions{
+					DisableIndexesStats:  true,
+					DisableTablesStats:   true,
+					DisableTriggersStats: true,
+				},
+			},
+			wantRows:      4,
+			wantExecution: 0.05,
+		},
+		{
+			name: "invalid input",
+			args: args{
+				input: `not a plan`,
+			},
+			wantErrStage: StageParse,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Explain(tt.args.input, tt.args.opts)
+			if tt.wantErrStage != "" {
+				var explainErr *ExplainError
+				if !errors.As(err, &explainErr) {
+					t.Fatalf("Explain() error = %v, want *ExplainError", err)
+				}
+				if explainErr.Stage != tt.wantErrStage {
+					t.Errorf("Explain() error stage = %v, want %v", explainErr.Stage, tt.wantErrStage)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(got.Summary) != tt.wantRows {
+				t.Errorf("Explain() summary rows = %v, want %v", len(got.Summary), tt.wantRows)
+			}
+			if len(got.TablesStats.Tables) != tt.wantTables {
+				t.Errorf("Explain() tables = %v, want %v", len(got.TablesStats.Tables), tt.wantTables)
+			}
+			if len(got.IndexesStats.Indexes) != tt.wantIndexes {
+				t.Errorf("Explain() indexes = %v, want %v", len(got.IndexesStats.Indexes), tt.wantIndexes)
+			}
+			if (got.TriggersStats != nil) != tt.wantTriggers {
+				t.Errorf("Explain() triggers = %v, want %v", got.TriggersStats, tt.wantTriggers)
+			}
+			if got.Stats.ExecutionTime != tt.wantExecution {
+				t.Errorf("Explain() execution time = %v, want %v", got.Stats.ExecutionTime, tt.wantExecution)
+			}
+			if got.Summary[0].Inclusive == 0 {
+				t.Errorf("Explain() root inclusive time was not computed")
+			}
+		})
+	}
+}
+
+func TestExplainAll(t *testing.T) {
+	tests := []struct {
+		name           string
+		input          string
+		wantExecutions []float64
+		wantErr        error
+	}{
+		{
+			name:           "several queries",
+			input:          `[` + explainTestPlan[1:len(explainTestPlan)-1] + `,{"Plan":{"Node Type":"Result","Startup Cost":0,"Total Cost":0.01,"Plan Rows":1,"Plan Width":4,"Actual Startup Time":0.001,"Actual Total Time":0.001,"Actual Rows":1,"Actual Loops":1},"Planning Time":0.01,"Triggers":[],"Execution Time":0.02}]`,
+			wantExecutions: []float64{0.05, 0.02},
+		},
+		{
+			name:    "empty plan",
+			input:   `[]`,
+			wantErr: ErrEmptyPlans,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExplainAll(tt.input, ExplainOptions{})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ExplainAll() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(got) != len(tt.wantExecutions) {
+				t.Fatalf("ExplainAll() = %v queries, want %v", len(got), len(tt.wantExecutions))
+			}
+			for i, explained := range got {
+				if explained.Stats.ExecutionTime != tt.wantExecutions[i] {
+					t.Errorf("ExplainAll() query %v execution time = %v, want %v", i, explained.Stats.ExecutionTime, tt.wantExecutions[i])
+				}
+			}
+			if got[0].TriggersStats == nil || got[1].TriggersStats != nil {
+				t.Errorf("ExplainAll() triggers were not kept per query")
+			}
+		})
+	}
+}
+
+func TestExplain_IDMode(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     IDMode
+		wantSame bool
+	}{
+		{
+			name:     "random",
+			mode:     IDModeRandom,
+			wantSame: false,
+		},
+		{
+			name:     "deterministic",
+			mode:     IDModeDeterministic,
+			wantSame: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			first, err := Explain(explainTestPlan, ExplainOptions{IDMode: tt.mode})
+			if err != nil {
+				t.Fatal(err)
+			}
+			second, err := Explain(explainTestPlan, ExplainOptions{IDMode: tt.mode})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ids := map[string]bool{}
+			for i, row := range first.Summary {
+				if (row.NodeId == second.Summary[i].NodeId) != tt.wantSame {
+					t.Errorf("Explain() row %v ids = %v and %v, want same %v", i, row.NodeId, second.Summary[i].NodeId, tt.wantSame)
+				}
+				if row.NodeParentId != second.Summary[i].NodeParentId && tt.wantSame {
+					t.Errorf("Explain() row %v parent ids = %v and %v", i, row.NodeParentId, second.Summary[i].NodeParentId)
+				}
+				if row.NodeShortId != float64(i+1) {
+					t.Errorf("Explain() row %v short id = %v, want %v", i, row.NodeShortId, i+1)
+				}
+				ids[row.NodeId] = true
+			}
+			if len(ids) != len(first.Summary) {
+				t.Errorf("Explain() ids are not unique: %v", ids)
+			}
+		})
+	}
+}
+
+func TestExplain_ShortIds(t *testing.T) {
+	tests := []struct {
+		name string
+		plan string
+		want map[string]float64
+	}{
+		{
+			name: "without CTE",
+			plan: explainTestPlan,
+			want: map[string]float64{"Inner Hash Join": 1, "Seq Scan": 2, "Hash": 3, "Index Scan": 4},
+		},
+		{
+			name: "CTE in the middle of the tree",
+			plan: `[{"Plan":{"Node Type":"Nested Loop","Join Type":"Inner","Total Cost":30,"Plan Rows":10,"Plans":[` +
+				`{"Node Type":"Hash Join","Parent Relationship":"Outer","Join Type":"Inner","Total Cost":20,"Plan Rows":10,"Plans":[` +
+				`{"Node Type":"Seq Scan","Parent Relationship":"InitPlan","Subplan Name":"CTE recent","Relation Name":"orders","Total Cost":5,"Plan Rows":10},` +
+				`{"Node Type":"CTE Scan","Parent Relationship":"Outer","CTE Name":"recent","Alias":"r","Total Cost":5,"Plan Rows":10},` +
+				`{"Node Type":"Hash","Parent Relationship":"Inner","Total Cost":5,"Plan Rows":10,"Plans":[` +
+				`{"Node Type":"Index Scan","Parent Relationship":"Outer","Index Name":"customers_pkey","Relation Name":"customers","Total Cost":5,"Plan Rows":10}]}]},` +
+				`{"Node Type":"Function Scan","Parent Relationship":"Inner","Function Name":"generate_series","Total Cost":5,"Plan Rows":10}]}}]`,
+			want: map[string]float64{"Inner Nested Loop": 1, "Inner Hash Join": 2, "Seq Scan": 3, "CTE Scan": 4, "Hash": 5, "Index Scan": 6, "Function Scan": 7},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			explained, err := Explain(tt.plan, ExplainOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := map[string]float64{}
+			for _, row := range explained.Summary {
+				got[row.Operation] = row.NodeShortId
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("short ids = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExplain_IOTimings(t *testing.T) {
+	plan := `[{"Plan":{"Node Type":"Hash Join","Join Type":"Inner","Total Cost":20,"Plan Rows":10,"Actual Startup Time":0.1,"Actual Total Time":10,"Actual Rows":10,"Actual Loops":1,"Shared Hit Blocks":0,"I/O Read Time":6,"I/O Write Time":0,` +
+		`"Plans":[{"Node Type":"Seq Scan","Parent Relationship":"Outer","Relation Name":"orders","Total Cost":10,"Plan Rows":10,"Actual Startup Time":0.1,"Actual Total Time":4,"Actual Rows":10,"Actual Loops":1,"Shared Hit Blocks":0,"I/O Read Time":3,"I/O Write Time":0},` +
+		`{"Node Type":"Seq Scan","Parent Relationship":"Inner","Relation Name":"customers","Total Cost":5,"Plan Rows":10,"Actual Startup Time":0.1,"Actual Total Time":2,"Actual Rows":10,"Actual Loops":1,"Shared Hit Blocks":0,"I/O Read Time":1,"I/O Write Time":0}]},` +
+		`"Execution Time":12}]`
+
+	explained, err := Explain(plan, ExplainOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		got  float64
+		want float64
+	}{
+		{name: "root exclusive read time", got: explained.Summary[0].IO.ExclusiveReadTime, want: 2},
+		{name: "root read time", got: explained.Summary[0].IO.ReadTime, want: 6},
+		{name: "root io percentage", got: explained.Summary[0].IO.Percentage, want: 50},
+		{name: "scan io percentage", got: explained.Summary[1].IO.Percentage, want: 75},
+		{name: "plan io time", got: explained.Stats.IOTime, want: 6},
+		{name: "plan cpu time", got: explained.Stats.CPUTime, want: 6},
+		{name: "plan io percentage", got: explained.Stats.IOPercentage, want: 50},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if math.Abs(tt.got-tt.want) > 1e-9 {
+				t.Errorf("got %v, want %v", tt.got, tt.want)
+			}
+		})
+	}
+
+	for _, table := range explained.TablesStats.Tables {
+		want := map[string]float64{"orders": 3, "customers": 1}[table.Name]
+		if table.IOTime != want {
+			t.Errorf("table %v io time = %v, want %v", table.Name, table.IOTime, want)
+		}
+	}
+}
+
+func TestExplain_SplitIOTimings(t *testing.T) {
+	tests := []struct {
+		name              string
+		plan              string
+		wantReadTime      float64
+		wantWriteTime     float64
+		wantExclusiveRead float64
+	}{
+		{
+			name: "PG16 shared, local and temp timings",
+			plan: `[{"Plan":{"Node Type":"Sort","Total Cost":20,"Plan Rows":10,"Actual Startup Time":0.1,"Actual Total Time":10,"Actual Rows":10,"Actual Loops":1,"Shared I/O Read Time":3.5,"Shared I/O Write Time":0,"Local I/O Read Time":0,"Local I/O Write Time":0,"Temp I/O Read Time":1.5,"Temp I/O Write Time":2,` +
+				`"Plans":[{"Node Type":"Seq Scan","Parent Relationship":"Outer","Relation Name":"orders","Total Cost":10,"Plan Rows":10,"Actual Startup Time":0.1,"Actual Total Time":4,"Actual Rows":10,"Actual Loops":1,"Shared I/O Read Time":3,"Shared I/O Write Time":0,"Local I/O Read Time":0.5,"Local I/O Write Time":0,"Temp I/O Read Time":0,"Temp I/O Write Time":0}]},` +
+				`"Execution Time":12}]`,
+			wantReadTime:      5,
+			wantWriteTime:     2,
+			wantExclusiveRead: 1.5,
+		},
+		{
+			name: "PG15 temp timings",
+			plan: `[{"Plan":{"Node Type":"Sort","Total Cost":20,"Plan Rows":10,"Actual Startup Time":0.1,"Actual Total Time":10,"Actual Rows":10,"Actual Loops":1,"I/O Read Time":3.5,"I/O Write Time":0,"Temp I/O Read Time":1.5,"Temp I/O Write Time":2,` +
+				`"Plans":[{"Node Type":"Seq Scan","Parent Relationship":"Outer","Relation Name":"orders","Total Cost":10,"Plan Rows":10,"Actual Startup Time":0.1,"Actual Total Time":4,"Actual Rows":10,"Actual Loops":1,"I/O Read Time":3.5,"I/O Write Time":0,"Temp I/O Read Time":0,"Temp I/O Write Time":0}]},` +
+				`"Execution Time":12}]`,
+			wantReadTime:      5,
+			wantWriteTime:     2,
+			wantExclusiveRead: 1.5,
+		},
+		{
+			name: "PG16 text format",
+			plan: `Sort  (cost=0.00..20.00 rows=10 width=0) (actual time=0.100..10.000 rows=10 loops=1)
+  I/O Timings: shared read=3.500, temp read=1.500 write=2.000
+  ->  Seq Scan on orders  (cost=0.00..10.00 rows=10 width=0) (actual time=0.100..4.000 rows=10 loops=1)
+        I/O Timings: shared read=3.000, local read=0.500
+Execution Time: 12.000 ms`,
+			wantReadTime:      5,
+			wantWriteTime:     2,
+			wantExclusiveRead: 1.5,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			explained, err := Explain(tt.plan, ExplainOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			root := explained.Summary[0].IO
+			if root.ReadTime != tt.wantReadTime || root.WriteTime != tt.wantWriteTime {
+				t.Errorf("read and write times = %v and %v, want %v and %v", root.ReadTime, root.WriteTime, tt.wantReadTime, tt.wantWriteTime)
+			}
+			if math.Abs(root.ExclusiveReadTime-tt.wantExclusiveRead) > 1e-9 {
+				t.Errorf("exclusive read time = %v, want %v", root.ExclusiveReadTime, tt.wantExclusiveRead)
+			}
+			if want := tt.wantReadTime + tt.wantWriteTime; math.Abs(explained.Stats.IOTime-want) > 1e-9 {
+				t.Errorf("plan io time = %v, want %v", explained.Stats.IOTime, want)
+			}
+		})
+	}
+}
+
+func TestExplain_Memoize(t *testing.T) {
+	memoize := func(hits, misses, evictions float64) string {
+		return fmt.Sprintf(`[{"Plan":{"Node Type":"Nested Loop","Join Type":"Inner","Total Cost":20,"Plan Rows":100,"Actual Startup Time":0.1,"Actual Total Time":5,"Actual Rows":100,"Actual Loops":1,"Plans":[`+
+			`{"Node Type":"Seq Scan","Parent Relationship":"Outer","Relation Name":"orders","Alias":"o","Total Cost":10,"Plan Rows":100,"Actual Startup Time":0.1,"Actual Total Time":1,"Actual Rows":100,"Actual Loops":1},`+
+			`{"Node Type":"Memoize","Parent Relationship":"Inner","Total Cost":0.3,"Plan Rows":1,"Actual Startup Time":0,"Actual Total Time":0.01,"Actual Rows":1,"Actual Loops":100,"Cache Key":"o.customer_id","Cache Mode":"logical","Cache Hits":%v,"Cache Misses":%v,"Cache Evictions":%v,"Cache Overflows":0,"Peak Memory Usage":12,`+
+			`"Workers":[{"Worker Number":0,"Actual Loops":50,"Actual Rows":1,"Actual Total Time":0.01,"Cache Hits":40,"Cache Misses":10,"Cache Evictions":0,"Cache Overflows":0,"Peak Memory Usage":6}],"Plans":[`+
+			`{"Node Type":"Index Scan","Parent Relationship":"Outer","Index Name":"customers_pkey","Relation Name":"customers","Alias":"c","Total Cost":0.3,"Plan Rows":1,"Actual Startup Time":0,"Actual Total Time":0.01,"Actual Rows":1,"Actual Loops":%v}]}]}}]`,
+			hits, misses, evictions, misses)
+	}
+
+	tests := []struct {
+		name         string
+		plan         string
+		wantRatio    float64
+		wantWarnings int
+		wantInfos    int
+	}{
+		{name: "efficient cache", plan: memoize(90, 10, 0), wantRatio: 90, wantWarnings: 0, wantInfos: 1},
+		{name: "inefficient cache", plan: memoize(20, 80, 30), wantRatio: 20, wantWarnings: 2, wantInfos: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			explained, err := Explain(tt.plan, ExplainOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			row := explained.Summary[2]
+			if row.Scopes.Key != "o.customer_id" {
+				t.Errorf("key = %v, want the cache key", row.Scopes.Key)
+			}
+
+			ratio := -1.0
+			for _, property := range row.NodeTypeSpecificProperties {
+				if property.ID == "cache_hit_ratio" {
+					ratio = property.ValueFloat
+				}
+			}
+			if ratio != tt.wantRatio {
+				t.Errorf("hit ratio = %v, want %v", ratio, tt.wantRatio)
+			}
+			if len(row.Warnings) != tt.wantWarnings || len(row.Infos) != tt.wantInfos {
+				t.Errorf("warnings = %v, infos = %v, want %v warnings and %v infos", row.Warnings, row.Infos, tt.wantWarnings, tt.wantInfos)
+			}
+
+			if len(row.Workers.List) != 1 || row.Workers.List[0][len(row.Workers.List[0])-1].ValueFloat != 80 {
+				t.Errorf("workers = %+v, want the worker hit ratio", row.Workers.List)
+			}
+		})
+	}
+}
+
+func TestExplain_BitmapHeapScan(t *testing.T) {
+	bitmap := func(heapBlocks string, recheck float64) string {
+		return fmt.Sprintf(`[{"Plan":{"Node Type":"Bitmap Heap Scan","Relation Name":"events","Alias":"e","Total Cost":100,"Plan Rows":1000,"Actual Startup Time":1,"Actual Total Time":10,"Actual Rows":900,"Actual Loops":1,"Recheck Cond":"(kind = 1)","Rows Removed by Index Recheck":%v,%v,"Plans":[`+
+			`{"Node Type":"Bitmap Index Scan","Parent Relationship":"Outer","Index Name":"events_kind_idx","Total Cost":10,"Plan Rows":1000,"Actual Startup Time":1,"Actual Total Time":1,"Actual Rows":1000,"Actual Loops":1,"Index Cond":"(kind = 1)"}]}}]`,
+			recheck, heapBlocks)
+	}
+
+	tests := []struct {
+		name         string
+		plan         string
+		wantExact    float64
+		wantLossy    float64
+		wantRecheck  float64
+		wantWarnings int
+	}{
+		{name: "exact", plan: bitmap(`"Exact Heap Blocks":40,"Lossy Heap Blocks":0`, 0), wantExact: 40, wantLossy: 0, wantRecheck: 0, wantWarnings: 0},
+		{name: "lossy", plan: bitmap(`"Exact Heap Blocks":10,"Lossy Heap Blocks":30`, 120), wantExact: 10, wantLossy: 30, wantRecheck: 120, wantWarnings: 1},
+		{name: "lossy heap blocks string", plan: bitmap(`"Heap Blocks":"exact=10 lossy=30"`, 120), wantExact: 10, wantLossy: 30, wantRecheck: 120, wantWarnings: 1},
+		{name: "exact heap blocks string", plan: bitmap(`"Heap Blocks":"exact=40"`, 0), wantExact: 40, wantLossy: 0, wantRecheck: 0, wantWarnings: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			explained, err := Explain(tt.plan, ExplainOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			row := explained.Summary[0]
+			blocks := map[string]float64{}
+			for _, property := range row.NodeTypeSpecificProperties {
+				if property.Type != "float" {
+					t.Errorf("property %v is a %v, want the heap blocks as numbers", property.ID, property.Type)
+				}
+				blocks[property.ID] = property.ValueFloat
+			}
+			if blocks["exact_heap_blocks"] != tt.wantExact || blocks["lossy_heap_blocks"] != tt.wantLossy {
+				t.Errorf("heap blocks = %v, want exact %v and lossy %v", blocks, tt.wantExact, tt.wantLossy)
+			}
+			if row.Rows.RemovedByRecheck != tt.wantRecheck {
+				t.Errorf("rows removed by recheck = %v, want %v", row.Rows.RemovedByRecheck, tt.wantRecheck)
+			}
+			if len(row.Warnings) != tt.wantWarnings {
+				t.Errorf("warnings = %v, want %v", row.Warnings, tt.wantWarnings)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package pkg
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strings"
+)
+
+var (
+	fingerprintStringRegex = regexp.MustCompile(`'(?:[^']|'')*'`)
+	fingerprintNumberRegex = regexp.MustCompile(`\$\d+|\b\d+(?:\.\d+)?(?:[eE][-+]?\d+)?\b`)
+	fingerprintSpaceRegex  = regexp.MustCompile(`\s+`)
+)
+
+// The conditions taking part in the fingerprint, their literals are stripped
+var fingerprintConditions = []string{
+	INDEX_CONDITION,
+	"Recheck Cond",
+	HASH_CONDITION_PROP,
+	"Merge Cond",
+	FILTER,
+	JOIN_FILTER,
+}
+
+// computeNodeFingerprint identifies the shape of the node and of its sub nodes, it doesn't depend on the
+// values of the literals nor on the measures, thus the same query gets the same fingerprint across executions.
+// The fingerprints of the sub nodes must have been computed already.
+func computeNodeFingerprint(node *PlanNode) string {
+	builder := strings.Builder{}
+
+	writeField := func(name string, value string) {
+		builder.WriteString(name)
+		builder.WriteString("=")
+		builder.WriteString(value)
+		builder.WriteString("\n")
+	}
+
+	writeField("operation", node.NodeType)
+	writeField("join", node.JoinType)
+	writeField("parent", node.ParentRelationship)
+	writeField("relation", node.RelationName)
+	writeField("cte", node.CTEName)
+	writeField("function", node.FunctionName)
+	writeField("index", node.IndexName)
+
+	for _, key := range fingerprintConditions {
+		if condition, ok := node.Get(key).(string); ok {
+			writeField(key, normalizeCondition(condition))
+		}
+	}
+
+	for _, child := range node.Plans {
+		writeField("child", child.Fingerprint)
+	}
+
+	sum := sha256.Sum256([]byte(builder.String()))
+	return hex.EncodeToString(sum[:8])
+}
+
+// normalizeCondition replaces the literals and the parameters with "?", ie. "(id = 42)" and "(id = 7)" are the same
+func normalizeCondition(condition string) string {
+	condition = fingerprintStringRegex.ReplaceAllString(condition, "?")
+	condition = fingerprintNumberRegex.ReplaceAllString(condition, "?")
+	return strings.TrimSpace(fingerprintSpaceRegex.ReplaceAllString(condition, " "))
+}
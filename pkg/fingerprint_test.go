@@ -0,0 +1,76 @@
+package pkg
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestNormalizeCondition(t *testing.T) {
+	tests := []struct {
+		condition string
+		want      string
+	}{
+		{condition: "(id = 42)", want: "(id = ?)"},
+		{condition: "((name)::text = 'O''Brien'::text)", want: "((name)::text = ?::text)"},
+		{condition: "(t1.amount > 1.5e3) AND (t1.id = $1)", want: "(t1.amount > ?) AND (t1.id = ?)"},
+		{condition: "(id = ANY ('{1,2,3}'::integer[]))", want: "(id = ANY (?::integer[]))"},
+		{condition: "(a.id   =\n b.id)", want: "(a.id = b.id)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.condition, func(t *testing.T) {
+			if got := normalizeCondition(tt.condition); got != tt.want {
+				t.Errorf("normalizeCondition() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestComputeNodeFingerprint(t *testing.T) {
+	const plan = `[{"Plan":{"Node Type":"Nested Loop","Join Type":"Inner","Total Cost":10,"Plan Rows":1,"Actual Rows":%v,"Actual Loops":1,"Actual Total Time":%v,"Plans":[{"Node Type":"Index Scan","Parent Relationship":"Outer","Relation Name":"a","Index Name":"%v","Index Cond":"(id = %v)","Total Cost":1,"Plan Rows":1,"Actual Rows":1,"Actual Loops":1,"Actual Total Time":0.1},{"Node Type":"Seq Scan","Parent Relationship":"Inner","Relation Name":"b","Filter":"(name = '%v'::text)","Total Cost":5,"Plan Rows":1,"Actual Rows":1,"Actual Loops":1,"Actual Total Time":0.2}]}}]`
+
+	fingerprints := func(rows string, time string, index string, id string, name string) []string {
+		explained, err := Explain(fmt.Sprintf(plan, rows, time, index, id, name), ExplainOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		result := make([]string, 0)
+		for _, row := range explained.Summary {
+			result = append(result, row.NodeFingerprint)
+		}
+		return result
+	}
+
+	reference := fingerprints("1", "0.5", "a_pkey", "1", "x")
+	for _, fingerprint := range reference {
+		if fingerprint == "" {
+			t.Fatalf("computeNodeFingerprint() = empty fingerprint")
+		}
+	}
+
+	tests := []struct {
+		name        string
+		fingerprint []string
+		wantSame    []bool
+	}{
+		{
+			name:        "other literals and measures",
+			fingerprint: fingerprints("100", "12.5", "a_pkey", "2", "y"),
+			wantSame:    []bool{true, true, true},
+		},
+		{
+			name:        "other index",
+			fingerprint: fingerprints("1", "0.5", "a_id_idx", "1", "x"),
+			wantSame:    []bool{false, false, true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i, fingerprint := range tt.fingerprint {
+				if (fingerprint == reference[i]) != tt.wantSame[i] {
+					t.Errorf("computeNodeFingerprint() row %v = %v, reference %v, want same %v", i, fingerprint, reference[i], tt.wantSame[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package pkg
+
+import (
+	"fmt"
+	"strings"
+)
+
+// foreignPushdowns tells what postgres_fdw pushed down to the remote server from the Relations of a Foreign Scan,
+// e.g. "(public.orders o) INNER JOIN (public.customers c)" or "Aggregate on (public.orders)"
+func foreignPushdowns(relations string) []string {
+	pushdowns := make([]string, 0)
+	if strings.Contains(relations, " JOIN ") {
+		pushdowns = append(pushdowns, "join")
+	}
+	if strings.HasPrefix(relations, "Aggregate on ") {
+		pushdowns = append(pushdowns, "aggregate")
+	}
+	return pushdowns
+}
+
+func getForeignScanProperties(node *PlanNode) ([]Property, error) {
+	props := make([]Property, 0)
+
+	values := map[string]string{}
+	for _, key := range []string{REMOTE_SQL, FOREIGN_RELATIONS} {
+		if node.Extra[key] == nil {
+			continue
+		}
+
+		value, err := stringProperty(node.Path, node.Extra, key)
+		if err != nil {
+			return nil, err
+		}
+		values[key] = value
+	}
+
+	if values[FOREIGN_RELATIONS] != "" {
+		props = append(props, Property{ID: "relations", Name: FOREIGN_RELATIONS, Type: "string", ValueString: values[FOREIGN_RELATIONS]})
+	}
+
+	pushdown := "none"
+	if pushdowns := foreignPushdowns(values[FOREIGN_RELATIONS]); len(pushdowns) > 0 {
+		pushdown = strings.Join(pushdowns, ", ")
+	}
+	props = append(props, Property{ID: "pushdown", Name: "Pushdown", Type: "string", ValueString: pushdown})
+
+	if node.Extra[ASYNC_CAPABLE] != nil {
+		async, err := boolProperty(node.Path, node.Extra, ASYNC_CAPABLE)
+		if err != nil {
+			return nil, err
+		}
+		props = append(props, Property{ID: "async_capable", Name: ASYNC_CAPABLE, Type: "string", ValueString: fmt.Sprintf("%v", async)})
+	}
+
+	if values[REMOTE_SQL] != "" {
+		props = append(props, Property{ID: "remote_sql", Name: REMOTE_SQL, Type: "string", ValueString: values[REMOTE_SQL]})
+	}
+
+	return props, nil
+}
+
+func getForeignScanInsights(node *PlanNode) ([]string, []string, error) {
+	var warnings, infos []string
+	if node.Filter != "" {
+		warning := fmt.Sprintf("Filter %v is applied locally, it can't be shipped to the remote server", node.Filter)
+		if node.RowsRemovedByFilterRevised > 0 {
+			warning += fmt.Sprintf(", %v rows were fetched only to be removed", node.RowsRemovedByFilterRevised)
+		}
+		warnings = append(warnings, warning)
+	}
+
+	if node.Extra[FOREIGN_RELATIONS] != nil {
+		relations, err := stringProperty(node.Path, node.Extra, FOREIGN_RELATIONS)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, pushdown := range foreignPushdowns(relations) {
+			infos = append(infos, fmt.Sprintf("The %v is pushed down to the remote server", pushdown))
+		}
+	}
+
+	return warnings, infos, nil
+}
+
+// The nodes passing the rows of their children through, e.g. the Append of the foreign partitions of a table
+var foreignPassThroughNodes = map[string]bool{
+	APPEND:       true,
+	MERGE_APPEND: true,
+	RESULT:       true,
+	MATERIALIZE:  true,
+	HASH:         true,
+}
+
+// foreignScans finds the foreign scans whose rows are read by a node, directly or through pass-through nodes
+func foreignScans(node *PlanNode) []*PlanNode {
+	if node.NodeType == FOREIGN_SCAN {
+		return []*PlanNode{node}
+	}
+	if !foreignPassThroughNodes[node.NodeType] {
+		return nil
+	}
+
+	scans := make([]*PlanNode, 0)
+	for _, member := range appendMembers(node) {
+		scans = append(scans, foreignScans(member)...)
+	}
+	return scans
+}
+
+// describeForeignScans names the relations of the foreign scans, the ones of a pushed down join are in Relations
+func describeForeignScans(scans []*PlanNode) string {
+	names := make([]string, 0, len(scans))
+	for _, scan := range scans {
+		switch relations, _ := scan.Extra[FOREIGN_RELATIONS].(string); {
+		case scan.RelationName != "":
+			names = append(names, qualifiedName(scan.Schema, scan.RelationName))
+		case relations != "":
+			names = append(names, relations)
+		}
+	}
+	if len(names) == 0 {
+		return "the foreign scans"
+	}
+	return strings.Join(names, ", ")
+}
+
+// getLocalSortInsights warns when the rows of foreign scans are sorted locally, the ORDER BY wasn't pushed down
+func getLocalSortInsights(node *PlanNode) ([]string, []string, error) {
+	for _, child := range node.Plans {
+		if child.ParentRelationship != "Outer" {
+			continue
+		}
+
+		scans := foreignScans(child)
+		if len(scans) == 0 {
+			continue
+		}
+		return []string{fmt.Sprintf("Rows of %v are sorted locally, the sort wasn't pushed down to the remote server",
+			describeForeignScans(scans))}, nil, nil
+	}
+
+	return nil, nil, nil
+}
+
+// getForeignJoinInsights warns when foreign scans are joined locally, the join and its Join Filter weren't pushed
+// down to the remote server
+func getForeignJoinInsights(node *PlanNode) ([]string, []string, error) {
+	members := appendMembers(node)
+	if len(members) != 2 {
+		return nil, nil, nil
+	}
+
+	var warnings []string
+	outer, inner := foreignScans(members[0]), foreignScans(members[1])
+	if len(outer) > 0 && len(inner) > 0 {
+		warnings = append(warnings, fmt.Sprintf("Join of %v with %v runs locally, it wasn't pushed down to the remote server",
+			describeForeignScans(outer), describeForeignScans(inner)))
+	}
+	if node.JoinFilter != "" && len(outer)+len(inner) > 0 {
+		warning := fmt.Sprintf("Join Filter %v is applied locally above the foreign scans", node.JoinFilter)
+		if node.RowsRemovedByJoinFilterRevised > 0 {
+			warning += fmt.Sprintf(", %v rows were removed by it", node.RowsRemovedByJoinFilterRevised)
+		}
+		warnings = append(warnings, warning)
+	}
+
+	return warnings, nil, nil
+}
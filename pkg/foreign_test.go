@@ -0,0 +1,139 @@
+package pkg
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestExplain_ForeignScan(t *testing.T) {
+	tests := []struct {
+		name         string
+		plan         string
+		row          int
+		wantPushdown string
+		wantWarnings []string
+		wantInfos    []string
+	}{
+		{
+			name: "join pushed down",
+			plan: `[{"Plan":{"Node Type":"Foreign Scan","Operation":"Select","Async Capable":false,"Total Cost":100,"Plan Rows":10,"Actual Startup Time":1,"Actual Total Time":2,"Actual Rows":10,"Actual Loops":1,` +
+				`"Relations":"(public.orders o) INNER JOIN (public.customers c)","Remote SQL":"SELECT o.id, c.name FROM (public.orders o INNER JOIN public.customers c ON (((o.customer_id = c.id))))"}}]`,
+			row:          0,
+			wantPushdown: "join",
+			wantInfos:    []string{"The join is pushed down to the remote server"},
+		},
+		{
+			name: "filter and sort applied locally",
+			plan: `[{"Plan":{"Node Type":"Sort","Total Cost":120,"Plan Rows":10,"Actual Startup Time":3,"Actual Total Time":3,"Actual Rows":10,"Actual Loops":1,"Sort Key":["(lower(name))"],"Sort Method":"quicksort","Sort Space Used":25,"Sort Space Type":"Memory","Plans":[` +
+				`{"Node Type":"Foreign Scan","Parent Relationship":"Outer","Operation":"Select","Relation Name":"customers","Schema":"public","Alias":"c","Total Cost":100,"Plan Rows":10,"Actual Startup Time":1,"Actual Total Time":2,"Actual Rows":10,"Actual Loops":1,` +
+				`"Filter":"(lower(c.name) ~~ 'a%'::text)","Rows Removed by Filter":90,"Remote SQL":"SELECT id, name FROM public.customers"}]}}]`,
+			row:          1,
+			wantPushdown: "none",
+			wantWarnings: []string{"Filter (lower(c.name) ~~ 'a%'::text) is applied locally, it can't be shipped to the remote server, 90 rows were fetched only to be removed"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			explained, err := Explain(tt.plan, ExplainOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			row := explained.Summary[tt.row]
+			properties := map[string]string{}
+			for _, property := range row.NodeTypeSpecificProperties {
+				properties[property.ID] = property.ValueString
+			}
+			if properties["pushdown"] != tt.wantPushdown {
+				t.Errorf("pushdown = %v, want %v", properties["pushdown"], tt.wantPushdown)
+			}
+			if properties["remote_sql"] == "" {
+				t.Errorf("remote SQL is missing from %+v", row.NodeTypeSpecificProperties)
+			}
+			if len(row.Warnings) != len(tt.wantWarnings) || len(row.Warnings) > 0 && row.Warnings[0] != tt.wantWarnings[0] {
+				t.Errorf("warnings = %v, want %v", row.Warnings, tt.wantWarnings)
+			}
+			if len(row.Infos) != len(tt.wantInfos) || len(row.Infos) > 0 && row.Infos[0] != tt.wantInfos[0] {
+				t.Errorf("infos = %v, want %v", row.Infos, tt.wantInfos)
+			}
+
+			if tt.row > 0 && len(explained.Summary[0].Warnings) != 1 {
+				t.Errorf("sort warnings = %v, want the local sort", explained.Summary[0].Warnings)
+			}
+		})
+	}
+}
+
+func TestExplain_ForeignLocalWork(t *testing.T) {
+	foreignScan := func(relation string, parentRelationship string) string {
+		return fmt.Sprintf(`{"Node Type":"Foreign Scan","Parent Relationship":"%v","Operation":"Select","Relation Name":"%v","Schema":"public","Alias":"%v","Total Cost":100,"Plan Rows":10,`+
+			`"Actual Startup Time":1,"Actual Total Time":2,"Actual Rows":10,"Actual Loops":1,"Remote SQL":"SELECT id FROM public.%v"}`, parentRelationship, relation, relation, relation)
+	}
+
+	tests := []struct {
+		name string
+		plan string
+		want map[int][]string
+	}{
+		{
+			name: "sort over the foreign partitions",
+			plan: `[{"Plan":{"Node Type":"Sort","Total Cost":220,"Plan Rows":20,"Actual Startup Time":5,"Actual Total Time":5,"Actual Rows":20,"Actual Loops":1,"Sort Key":["orders.id"],"Plans":[` +
+				`{"Node Type":"Append","Parent Relationship":"Outer","Total Cost":200,"Plan Rows":20,"Actual Startup Time":1,"Actual Total Time":4,"Actual Rows":20,"Actual Loops":1,"Plans":[` +
+				foreignScan("orders_2023", "Member") + `,` + foreignScan("orders_2024", "Member") + `]}]}}]`,
+			want: map[int][]string{
+				0: {"Rows of public.orders_2023, public.orders_2024 are sorted locally, the sort wasn't pushed down to the remote server"},
+				1: nil,
+			},
+		},
+		{
+			name: "merge append of sorted foreign partitions",
+			plan: `[{"Plan":{"Node Type":"Merge Append","Total Cost":220,"Plan Rows":20,"Actual Startup Time":5,"Actual Total Time":5,"Actual Rows":20,"Actual Loops":1,"Sort Key":["orders.id"],"Plans":[` +
+				`{"Node Type":"Sort","Parent Relationship":"Member","Total Cost":110,"Plan Rows":10,"Actual Startup Time":2,"Actual Total Time":2,"Actual Rows":10,"Actual Loops":1,"Sort Key":["orders.id"],"Plans":[` + foreignScan("orders_2023", "Outer") + `]},` +
+				`{"Node Type":"Sort","Parent Relationship":"Member","Total Cost":110,"Plan Rows":10,"Actual Startup Time":2,"Actual Total Time":2,"Actual Rows":10,"Actual Loops":1,"Sort Key":["orders.id"],"Plans":[` + foreignScan("orders_2024", "Outer") + `]}]}}]`,
+			want: map[int][]string{
+				0: nil,
+				1: {"Rows of public.orders_2023 are sorted locally, the sort wasn't pushed down to the remote server"},
+				3: {"Rows of public.orders_2024 are sorted locally, the sort wasn't pushed down to the remote server"},
+			},
+		},
+		{
+			name: "join of foreign scans not pushed down",
+			plan: `[{"Plan":{"Node Type":"Hash Join","Join Type":"Inner","Total Cost":220,"Plan Rows":10,"Actual Startup Time":5,"Actual Total Time":5,"Actual Rows":10,"Actual Loops":1,` +
+				`"Hash Cond":"(orders.customer_id = customers.id)","Join Filter":"(orders.total > customers.credit)","Rows Removed by Join Filter":5,"Plans":[` +
+				foreignScan("orders", "Outer") + `,` +
+				`{"Node Type":"Hash","Parent Relationship":"Inner","Total Cost":100,"Plan Rows":10,"Actual Startup Time":2,"Actual Total Time":2,"Actual Rows":10,"Actual Loops":1,"Plans":[` + foreignScan("customers", "Outer") + `]}]}}]`,
+			want: map[int][]string{
+				0: {
+					"Join of public.orders with public.customers runs locally, it wasn't pushed down to the remote server",
+					"Join Filter (orders.total > customers.credit) is applied locally above the foreign scans, 5 rows were removed by it",
+				},
+			},
+		},
+		{
+			name: "join of a foreign scan with a local table",
+			plan: `[{"Plan":{"Node Type":"Nested Loop","Join Type":"Inner","Total Cost":220,"Plan Rows":10,"Actual Startup Time":5,"Actual Total Time":5,"Actual Rows":10,"Actual Loops":1,"Plans":[` +
+				`{"Node Type":"Seq Scan","Parent Relationship":"Outer","Relation Name":"customers","Alias":"customers","Total Cost":10,"Plan Rows":10,"Actual Startup Time":0,"Actual Total Time":1,"Actual Rows":10,"Actual Loops":1},` +
+				foreignScan("orders", "Inner") + `]}}]`,
+			want: map[int][]string{
+				0: nil,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			explained, err := Explain(tt.plan, ExplainOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for i, want := range tt.want {
+				if got := explained.Summary[i].Warnings; len(got) > 0 || len(want) > 0 {
+					if !reflect.DeepEqual(got, want) {
+						t.Errorf("%v warnings = %v, want %v", explained.Summary[i].Operation, got, want)
+					}
+				}
+			}
+		})
+	}
+}
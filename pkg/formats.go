@@ -0,0 +1,76 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+type Format = string
+
+const (
+	FormatJSON = Format("json")
+	FormatText = Format("text")
+	FormatYAML = Format("yaml")
+	FormatXML  = Format("xml")
+)
+
+// DetectFormat guesses which of the EXPLAIN formats the plan has been produced with
+func DetectFormat(plan string) Format {
+	trimmed := strings.TrimSpace(plan)
+
+	switch {
+	case strings.HasPrefix(trimmed, "[") || strings.HasPrefix(trimmed, "{"):
+		return FormatJSON
+	case strings.HasPrefix(trimmed, "<"):
+		return FormatXML
+	case isYAMLSequenceItem(strings.SplitN(trimmed, "\n", 2)[0]):
+		return FormatYAML
+	default:
+		return FormatText
+	}
+}
+
+// wrapJSONObject puts a plan made of a single query, e.g. copied out of the array printed by EXPLAIN, back into an array
+func wrapJSONObject(plan string) string {
+	if trimmed := strings.TrimSpace(plan); strings.HasPrefix(trimmed, "{") {
+		return "[" + trimmed + "]"
+	}
+	return plan
+}
+
+// ConvertPlanToJSON converts a plan in any of the supported formats into the FORMAT JSON document
+// expected by GetRootNodeFromPlans and StatsGather.GetStatsFromPlans, the psql decoration is removed with CleanPlan
+func ConvertPlanToJSON(plan string) (string, error) {
+	converted, _, err := ConvertPlanToJSONWithReport(plan)
+	return converted, err
+}
+
+// ConvertPlanToJSONWithReport is ConvertPlanToJSON, the report tells what CleanPlan removed around the plan
+func ConvertPlanToJSONWithReport(plan string) (string, CleanupReport, error) {
+	var plans []interface{}
+	var err error
+
+	plan, report := CleanPlan(plan)
+
+	switch DetectFormat(plan) {
+	case FormatJSON:
+		return wrapJSONObject(plan), report, nil
+	case FormatXML:
+		plans, err = ParseXMLPlan(plan)
+	case FormatYAML:
+		plans, err = ParseYAMLPlan(plan)
+	default:
+		plans, err = ParseTextPlan(plan)
+	}
+	if err != nil {
+		return "", report, err
+	}
+
+	marshal, err := json.Marshal(plans)
+	if err != nil {
+		return "", report, fmt.Errorf("could not marshal plan: %v", err)
+	}
+
+	return string(marshal), report, nil
+}
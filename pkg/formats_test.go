@@ -0,0 +1,246 @@
+package pkg
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const formatsTestJSON = `[{"Plan":{"Node Type":"Sort","Parallel Aware":false,"Startup Cost":1.05,"Total Cost":1.06,"Plan Rows":3,"Plan Width":36,"Actual Startup Time":0.02,"Actual Total Time":0.021,"Actual Rows":3,"Actual Loops":1,"Sort Key":["name","(id > 1)"],"Sort Method":"quicksort","Sort Space Used":25,"Sort Space Type":"Memory","Full-sort Groups":{"Group Count":1},"Plans":[{"Node Type":"Seq Scan","Parent Relationship":"Outer","Parallel Aware":false,"Relation Name":"users","Alias":"u","Startup Cost":0,"Total Cost":1.03,"Plan Rows":3,"Plan Width":36,"Actual Startup Time":0.005,"Actual Total Time":0.006,"Actual Rows":3,"Actual Loops":1,"Filter":"(name <> 'a: b')","Rows Removed by Filter":0}]},"Planning Time":0.05,"Triggers":[],"Execution Time":0.04}]`
+
+const formatsTestYAML = `- Plan: 
+    Node Type: "Sort"
+    Parallel Aware: false
+    Startup Cost: 1.05
+    Total Cost: 1.06
+    Plan Rows: 3
+    Plan Width: 36
+    Actual Startup Time: 0.020
+    Actual Total Time: 0.021
+    Actual Rows: 3
+    Actual Loops: 1
+    Sort Key: 
+      - "name"
+      - "(id > 1)"
+    Sort Method: "quicksort"
+    Sort Space Used: 25
+    Sort Space Type: "Memory"
+    Full-sort Groups: 
+      Group Count: 1
+    Plans: 
+      - Node Type: "Seq Scan"
+        Parent Relationship: "Outer"
+        Parallel Aware: false
+        Relation Name: "users"
+        Alias: "u"
+        Startup Cost: 0.00
+        Total Cost: 1.03
+        Plan Rows: 3
+        Plan Width: 36
+        Actual Startup Time: 0.005
+        Actual Total Time: 0.006
+        Actual Rows: 3
+        Actual Loops: 1
+        Filter: "(name <> 'a: b')"
+        Rows Removed by Filter: 0
+  Planning Time: 0.050
+  Triggers: 
+  Execution Time: 0.040`
+
+const formatsTestXML = `<explain xmlns="http://www.postgresql.org/2009/explain">
+  <Query>
+    <Plan>
+      <Node-Type>Sort</Node-Type>
+      <Parallel-Aware>false</Parallel-Aware>
+      <Startup-Cost>1.05</Startup-Cost>
+      <Total-Cost>1.06</Total-Cost>
+      <Plan-Rows>3</Plan-Rows>
+      <Plan-Width>36</Plan-Width>
+      <Actual-Startup-Time>0.020</Actual-Startup-Time>
+      <Actual-Total-Time>0.021</Actual-Total-Time>
+      <Actual-Rows>3</Actual-Rows>
+      <Actual-Loops>1</Actual-Loops>
+      <Sort-Key>
+        <Item>name</Item>
+        <Item>(id &gt; 1)</Item>
+      </Sort-Key>
+      <Sort-Method>quicksort</Sort-Method>
+      <Sort-Space-Used>25</Sort-Space-Used>
+      <Sort-Space-Type>Memory</Sort-Space-Type>
+      <Full-sort-Groups>
+        <Group-Count>1</Group-Count>
+      </Full-sort-Groups>
+      <Plans>
+        <Plan>
+          <Node-Type>Seq Scan</Node-Type>
+          <Parent-Relationship>Outer</Parent-Relationship>
+          <Parallel-Aware>false</Parallel-Aware>
+          <Relation-Name>users</Relation-Name>
+          <Alias>u</Alias>
+          <Startup-Cost>0.00</Startup-Cost>
+          <Total-Cost>1.03</Total-Cost>
+          <Plan-Rows>3</Plan-Rows>
+          <Plan-Width>36</Plan-Width>
+          <Actual-Startup-Time>0.005</Actual-Startup-Time>
+          <Actual-Total-Time>0.006</Actual-Total-Time>
+          <Actual-Rows>3</Actual-Rows>
+          <Actual-Loops>1</Actual-Loops>
+          <Filter>(name &lt;&gt; 'a: b')</Filter>
+          <Rows-Removed-by-Filter>0</Rows-Removed-by-Filter>
+        </Plan>
+      </Plans>
+    </Plan>
+    <Planning-Time>0.050</Planning-Time>
+    <Triggers>
+    </Triggers>
+    <Execution-Time>0.040</Execution-Time>
+  </Query>
+</explain>`
+
+func TestConvertPlanToJSON(t *testing.T) {
+	tests := []struct {
+		name       string
+		plan       string
+		wantFormat Format
+	}{
+		{
+			name:       "json",
+			plan:       formatsTestJSON,
+			wantFormat: FormatJSON,
+		},
+		{
+			name:       "json object",
+			plan:       "\n" + strings.TrimSuffix(strings.TrimPrefix(formatsTestJSON, "["), "]"),
+			wantFormat: FormatJSON,
+		},
+		{
+			name:       "yaml",
+			plan:       formatsTestYAML,
+			wantFormat: FormatYAML,
+		},
+		{
+			name:       "xml",
+			plan:       formatsTestXML,
+			wantFormat: FormatXML,
+		},
+	}
+
+	var want interface{}
+	if err := json.Unmarshal([]byte(formatsTestJSON), &want); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectFormat(tt.plan); got != tt.wantFormat {
+				t.Errorf("DetectFormat() = %v, want %v", got, tt.wantFormat)
+			}
+
+			converted, err := ConvertPlanToJSON(tt.plan)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got interface{}
+			if err := json.Unmarshal([]byte(converted), &got); err != nil {
+				t.Fatal(err)
+			}
+
+			// FORMAT YAML prints empty groups without any value
+			if tt.wantFormat == FormatYAML {
+				got.([]interface{})[0].(map[string]interface{})["Triggers"] = []interface{}{}
+			}
+
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("ConvertPlanToJSON() = %v, want %v", converted, formatsTestJSON)
+			}
+
+			if _, err := Explain(tt.plan, ExplainOptions{}); err != nil {
+				t.Errorf("Explain() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestParseXMLPlan_ScalarTypes(t *testing.T) {
+	const plan = `<explain xmlns="http://www.postgresql.org/2009/explain">
+  <Query>
+    <Plan>
+      <Node-Type>Seq Scan</Node-Type>
+      <Parallel-Aware>false</Parallel-Aware>
+      <Relation-Name>2024</Relation-Name>
+      <Alias>inf</Alias>
+      <Startup-Cost>0.00</Startup-Cost>
+      <Total-Cost>1.03</Total-Cost>
+      <Plan-Rows>3</Plan-Rows>
+      <Plan-Width>36</Plan-Width>
+      <Filter>nan</Filter>
+    </Plan>
+  </Query>
+</explain>`
+
+	tests := []struct {
+		key  string
+		want interface{}
+	}{
+		{key: "Relation Name", want: "2024"},
+		{key: "Alias", want: "inf"},
+		{key: "Filter", want: "nan"},
+		{key: "Parallel Aware", want: false},
+		{key: "Total Cost", want: 1.03},
+		{key: "Plan Rows", want: 3.0},
+	}
+
+	plans, err := ParseXMLPlan(plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	node := plans[0].(Node)["Plan"].(Node)
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			if got := node[tt.key]; got != tt.want {
+				t.Errorf("%v = %#v, want %#v", tt.key, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := Explain(plan, ExplainOptions{}); err != nil {
+		t.Errorf("Explain() error = %v", err)
+	}
+}
+
+func TestParseXMLPlan_VerboseOutput(t *testing.T) {
+	// EXPLAIN (VERBOSE, FORMAT XML) SELECT 1, 2.5, 'inf'
+	const plan = `<explain xmlns="http://www.postgresql.org/2009/explain">
+  <Query>
+    <Plan>
+      <Node-Type>Result</Node-Type>
+      <Parallel-Aware>false</Parallel-Aware>
+      <Async-Capable>false</Async-Capable>
+      <Startup-Cost>0.00</Startup-Cost>
+      <Total-Cost>0.01</Total-Cost>
+      <Plan-Rows>1</Plan-Rows>
+      <Plan-Width>44</Plan-Width>
+      <Output>
+        <Item>1</Item>
+        <Item>2.5</Item>
+        <Item>'inf'::text</Item>
+      </Output>
+    </Plan>
+  </Query>
+</explain>`
+
+	plans, err := ParseXMLPlan(plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []interface{}{"1", "2.5", "'inf'::text"}
+	if got := plans[0].(Node)["Plan"].(Node)[OUTPUT]; !reflect.DeepEqual(got, want) {
+		t.Errorf("Output = %#v, want %#v", got, want)
+	}
+
+	if _, err := Explain(plan, ExplainOptions{}); err != nil {
+		t.Errorf("Explain() error = %v", err)
+	}
+}
@@ -0,0 +1,200 @@
+package pkg
+
+import "strings"
+
+const (
+	// A node is wide when it outputs at least that many columns, or rows of at least that many bytes
+	wideOutputColumns = 10
+	wideOutputWidth   = 256.0
+
+	// Number of levels a column goes up unchanged before it's used, to be reported. 1 is the parent.
+	carriedOutputDistance = 3
+)
+
+// The properties in which a node uses the columns of its sub nodes, the Output is handled apart
+var outputReferencingProperties = []string{
+	FILTER,
+	JOIN_FILTER,
+	INDEX_CONDITION,
+	"Recheck Cond",
+	HASH_CONDITION_PROP,
+	"Merge Cond",
+	"One-Time Filter",
+	"TID Cond",
+	"Order By",
+	SORT_KEY,
+	GROUP_KEY,
+	PRESORTED_KEY,
+	"Cache Key",
+	"Hash Key",
+	"Partition Key",
+}
+
+// The nodes whose output doesn't keep the names of the columns of their sub nodes, e.g. each member of an
+// Append is named after the first one. The columns are considered used once they reach one of them.
+var renamingOutputNodes = map[string]bool{
+	SUBQUERY_SCAN:       true,
+	APPEND:              true,
+	MERGE_APPEND:        true,
+	RECURSIVE_UNION:     true,
+	SET_OP:              true,
+	CUSTOM_SCAN:         true,
+	FOREIGN_SCAN:        true,
+	FUNCTION_SCAN:       true,
+	PROJECT_SET:         true,
+	TABLE_FUNCTION_SCAN: true,
+}
+
+// ComputeOutputStats analyses the columns output by the nodes of a VERBOSE plan, it returns nil for the other plans
+func (s *StatsGather) ComputeOutputStats(node *PlanNode) *OutputStats {
+	if node.Output == nil {
+		return nil
+	}
+
+	stats := &OutputStats{
+		WideNodes:     make([]WideOutputNode, 0),
+		UnusedColumns: make([]UnusedColumnsNode, 0),
+	}
+	s.computeOutputStats(stats, node, nil)
+
+	return stats
+}
+
+// ancestors are ordered from the parent up to the root of the plan, or of the sub plan
+func (s *StatsGather) computeOutputStats(stats *OutputStats, node *PlanNode, ancestors []*PlanNode) {
+	if len(ancestors) > 0 && node.Output != nil {
+		var carried, unused []string
+		distance := 0
+
+		for _, column := range node.Output {
+			used, at := columnUsage(column, ancestors)
+			if !used {
+				unused = append(unused, column)
+				continue
+			}
+			if at >= carriedOutputDistance {
+				carried = append(carried, column)
+				if at > distance {
+					distance = at
+				}
+			}
+		}
+
+		isWide := len(node.Output) >= wideOutputColumns || node.PlanWidth >= wideOutputWidth
+		if isWide && len(carried) > 0 {
+			stats.WideNodes = append(stats.WideNodes, WideOutputNode{
+				Id:             node.NodeId,
+				Type:           node.NodeType,
+				Columns:        len(node.Output),
+				Width:          node.PlanWidth,
+				CarriedColumns: carried,
+				Distance:       distance,
+			})
+		}
+
+		if node.RelationName != "" && len(unused) > 0 {
+			stats.UnusedColumns = append(stats.UnusedColumns, UnusedColumnsNode{
+				Id:      node.NodeId,
+				Type:    node.NodeType,
+				Table:   qualifiedName(node.Schema, node.RelationName),
+				Alias:   node.Alias,
+				Columns: unused,
+				Total:   len(node.Output),
+			})
+		}
+	}
+
+	for _, child := range node.Plans {
+		// The output of a sub plan is used by the expressions of its parent, e.g. (SubPlan 1), not by its columns
+		if child.ParentRelationship == "InitPlan" || child.ParentRelationship == "SubPlan" {
+			s.computeOutputStats(stats, child, nil)
+			continue
+		}
+		s.computeOutputStats(stats, child, append([]*PlanNode{node}, ancestors...))
+	}
+}
+
+// columnUsage walks up the ancestors until the column is used, it returns the distance to the one using it, or
+// false when an ancestor drops the column without having used it
+func columnUsage(column string, ancestors []*PlanNode) (bool, int) {
+	for i, ancestor := range ancestors {
+		distance := i + 1
+
+		// Without an output, e.g. ModifyTable, the columns are used by the node itself
+		if ancestor.Output == nil || renamingOutputNodes[ancestor.NodeType] {
+			return true, distance
+		}
+
+		for _, expression := range outputReferences(ancestor) {
+			if referencesColumn(expression, column) {
+				return true, distance
+			}
+		}
+
+		passed := false
+		for _, expression := range ancestor.Output {
+			if expression == column {
+				passed = true
+				continue
+			}
+			if referencesColumn(expression, column) {
+				return true, distance
+			}
+		}
+		if !passed {
+			return false, distance
+		}
+	}
+
+	// Output by the root, the column is used by the client
+	return true, len(ancestors)
+}
+
+func outputReferences(node *PlanNode) []string {
+	var references []string
+	for _, key := range outputReferencingProperties {
+		switch value := node.Get(key).(type) {
+		case string:
+			references = append(references, value)
+		case []interface{}:
+			for _, item := range value {
+				if expression, ok := item.(string); ok {
+					references = append(references, expression)
+				}
+			}
+		}
+	}
+	return references
+}
+
+// referencesColumn looks for the column as a whole identifier, e.g. o.id is in (o.id = c.order_id) but not in
+// o.id_customer, a reference to the whole row, e.g. o.*, references all its columns
+func referencesColumn(expression string, column string) bool {
+	if dot := strings.LastIndex(column, "."); dot > 0 && containsIdentifier(expression, column[:dot]+".*") {
+		return true
+	}
+	return containsIdentifier(expression, column)
+}
+
+func containsIdentifier(expression string, identifier string) bool {
+	for start := 0; start < len(expression); {
+		i := strings.Index(expression[start:], identifier)
+		if i < 0 {
+			return false
+		}
+		i += start
+
+		end := i + len(identifier)
+		before := i == 0 || !isIdentifierChar(expression[i-1]) && expression[i-1] != '.'
+		after := end == len(expression) || !isIdentifierChar(expression[end])
+		if before && after {
+			return true
+		}
+		start = i + 1
+	}
+	return false
+}
+
+func isIdentifierChar(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
@@ -0,0 +1,84 @@
+package pkg
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReferencesColumn(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		column     string
+		want       bool
+	}{
+		{name: "condition", expression: "(o.id = c.order_id)", column: "o.id", want: true},
+		{name: "prefix of another column", expression: "(o.id_customer = c.id)", column: "o.id", want: false},
+		{name: "suffix of another column", expression: "(co.id = 1)", column: "o.id", want: false},
+		{name: "whole row", expression: "row_to_json(o.*)", column: "o.payload", want: true},
+		{name: "aggregate", expression: "sum(o.amount)", column: "o.amount", want: true},
+		{name: "other column", expression: "sum(o.amount)", column: "o.id", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := referencesColumn(tt.expression, tt.column); got != tt.want {
+				t.Errorf("referencesColumn() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatsGather_ComputeOutputStats(t *testing.T) {
+	wideColumns := `"o.id","o.customer_id","o.c1","o.c2","o.c3","o.c4","o.c5","o.c6","o.c7","o.c8"`
+
+	tests := []struct {
+		name string
+		plan string
+		want *OutputStats
+	}{
+		{
+			name: "not verbose",
+			plan: `[{"Plan":{"Node Type":"Seq Scan","Relation Name":"orders","Alias":"o","Total Cost":10,"Plan Rows":10}}]`,
+			want: nil,
+		},
+		{
+			name: "unused columns",
+			plan: `[{"Plan":{"Node Type":"Aggregate","Total Cost":20,"Plan Rows":1,"Output":["sum(o.amount)"],"Plans":[` +
+				`{"Node Type":"Seq Scan","Parent Relationship":"Outer","Relation Name":"orders","Schema":"public","Alias":"o","Total Cost":10,"Plan Rows":10,"Output":["o.id","o.amount","o.payload"]}]}}]`,
+			want: &OutputStats{
+				WideNodes: []WideOutputNode{},
+				UnusedColumns: []UnusedColumnsNode{
+					{Table: "public.orders", Type: "Seq Scan", Alias: "o", Columns: []string{"o.id", "o.payload"}, Total: 3},
+				},
+			},
+		},
+		{
+			name: "wide node carried to the root",
+			plan: `[{"Plan":{"Node Type":"Limit","Total Cost":30,"Plan Rows":10,"Output":[` + wideColumns + `],"Plans":[` +
+				`{"Node Type":"Sort","Parent Relationship":"Outer","Total Cost":30,"Plan Rows":10,"Sort Key":["o.c1"],"Output":[` + wideColumns + `],"Plans":[` +
+				`{"Node Type":"Hash Join","Parent Relationship":"Outer","Join Type":"Inner","Total Cost":20,"Plan Rows":10,"Hash Cond":"(o.customer_id = c.id)","Output":[` + wideColumns + `],"Plans":[` +
+				`{"Node Type":"Seq Scan","Parent Relationship":"Outer","Relation Name":"orders","Alias":"o","Total Cost":10,"Plan Rows":10,"Output":[` + wideColumns + `]},` +
+				`{"Node Type":"Hash","Parent Relationship":"Inner","Total Cost":5,"Plan Rows":10,"Output":["c.id"],"Plans":[` +
+				`{"Node Type":"Seq Scan","Parent Relationship":"Outer","Relation Name":"customers","Alias":"c","Total Cost":5,"Plan Rows":10,"Output":["c.id"]}]}]}]}]}}]`,
+			want: &OutputStats{
+				WideNodes: []WideOutputNode{
+					{Type: "Seq Scan", Columns: 10, CarriedColumns: []string{"o.id", "o.c2", "o.c3", "o.c4", "o.c5", "o.c6", "o.c7", "o.c8"}, Distance: 3},
+				},
+				UnusedColumns: []UnusedColumnsNode{},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := GetRootNodeFromPlans(tt.plan)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Not enriched, the ids are left empty
+			if got := NewStatsGather().ComputeOutputStats(node); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ComputeOutputStats() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package pkg
+
+import (
+	"fmt"
+	"math"
+)
+
+// Above that ratio between the rows of the busiest worker and the average the work is reported as skewed
+const gatherSkewThreshold = 1.5
+
+// gatherStats describes the parallel subtree below a Gather or a Gather Merge. The per worker figures are read
+// from the first node of the subtree, they are only reported with VERBOSE, thus the ones depending on them are
+// nil without it.
+type gatherStats struct {
+	planned    float64
+	launched   float64
+	singleCopy bool
+
+	// Share of the rows produced by the leader, in percent
+	leaderParticipation *float64
+
+	// The busiest worker against the average one, 1 when the work is evenly spread
+	rowsSkew *float64
+	timeSkew *float64
+
+	// The launched workers against the planned ones, weighted by the balance of the work between them, in percent
+	efficiency *float64
+}
+
+func newGatherStats(node *PlanNode) (*gatherStats, error) {
+	stats := &gatherStats{
+		planned:  floatValue(node.WorkersPlanned),
+		launched: floatValue(node.WorkersLaunched),
+	}
+
+	if node.Extra[SINGLE_COPY] != nil {
+		singleCopy, err := boolProperty(node.Path, node.Extra, SINGLE_COPY)
+		if err != nil {
+			return nil, err
+		}
+		stats.singleCopy = singleCopy
+	}
+
+	balance := 1.0
+	if subtree := parallelSubtree(node); subtree != nil && len(subtree.Workers) > 0 {
+		// Without VERBOSE the workers of some nodes, e.g. a Sort, only report their own properties
+		rows, times := make([]float64, 0, len(subtree.Workers)), make([]float64, 0, len(subtree.Workers))
+		counted, timed := true, true
+		for i, w := range subtree.Workers {
+			path := childPath(subtree.Path, WORKERS, i)
+			values := map[string]*float64{}
+			for _, key := range []string{ACTUAL_ROWS, ACTUAL_LOOPS, ACTUAL_TOTAL_TIME} {
+				value, err := optionalFloatProperty(path, w, key)
+				if err != nil {
+					return nil, err
+				}
+				values[key] = value
+			}
+
+			if values[ACTUAL_ROWS] == nil || values[ACTUAL_LOOPS] == nil {
+				counted, timed = false, false
+				continue
+			}
+			rows = append(rows, *values[ACTUAL_ROWS]**values[ACTUAL_LOOPS])
+
+			if values[ACTUAL_TOTAL_TIME] == nil {
+				timed = false
+				continue
+			}
+			times = append(times, *values[ACTUAL_TOTAL_TIME]**values[ACTUAL_LOOPS])
+		}
+
+		totalRows := floatValue(subtree.ActualRows) * floatValue(subtree.ActualLoops)
+		if counted && totalRows > 0 && !stats.singleCopy {
+			leaderRows := math.Max(totalRows-sum(rows), 0)
+			leaderParticipation := leaderRows / totalRows * 100
+			stats.leaderParticipation = &leaderParticipation
+		}
+
+		if counted {
+			stats.rowsSkew = skew(rows)
+		}
+		if timed {
+			stats.timeSkew = skew(times)
+		}
+		if stats.timeSkew != nil {
+			balance = 1 / *stats.timeSkew
+		}
+	}
+
+	if stats.planned > 0 {
+		efficiency := math.Min(stats.launched/stats.planned, 1) * balance * 100
+		stats.efficiency = &efficiency
+	}
+
+	return stats, nil
+}
+
+// parallelSubtree returns the node run by the workers, the InitPlans and the SubPlans of the Gather are run by the leader
+func parallelSubtree(node *PlanNode) *PlanNode {
+	for _, child := range node.Plans {
+		if child.ParentRelationship != "InitPlan" && child.ParentRelationship != "SubPlan" {
+			return child
+		}
+	}
+	return nil
+}
+
+func getGatherProperties(node *PlanNode) ([]Property, error) {
+	stats, err := newGatherStats(node)
+	if err != nil {
+		return nil, err
+	}
+
+	props := []Property{
+		{ID: "workers_planned", Name: WORKERS_PLANNED, Type: "float", ValueFloat: stats.planned, Kind: Quantity},
+		{ID: "workers_launched", Name: WORKERS_LAUNCHED, Type: "float", ValueFloat: stats.launched, Kind: Quantity},
+		{ID: "single_copy", Name: SINGLE_COPY, Type: "string", ValueString: fmt.Sprintf("%v", stats.singleCopy)},
+	}
+
+	for _, property := range []struct {
+		value *float64
+		Property
+	}{
+		{stats.leaderParticipation, Property{ID: "leader_participation", Name: "Leader Participation", Kind: Percentage}},
+		{stats.rowsSkew, Property{ID: "rows_skew", Name: "Workers Rows Skew", Kind: Quantity}},
+		{stats.timeSkew, Property{ID: "time_skew", Name: "Workers Time Skew", Kind: Quantity}},
+		{stats.efficiency, Property{ID: "parallel_efficiency", Name: "Parallel Efficiency", Kind: Percentage}},
+	} {
+		if property.value == nil {
+			continue
+		}
+
+		property.Type = "float"
+		property.ValueFloat = *property.value
+		props = append(props, property.Property)
+	}
+
+	return props, nil
+}
+
+func getGatherInsights(node *PlanNode) ([]string, []string, error) {
+	stats, err := newGatherStats(node)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var warnings, infos []string
+	if node.WorkersLaunched != nil && stats.launched < stats.planned {
+		warnings = append(warnings, fmt.Sprintf("Only %v of %v planned workers were launched, max_parallel_workers or max_worker_processes is exhausted", stats.launched, stats.planned))
+	}
+	if stats.rowsSkew != nil && *stats.rowsSkew >= gatherSkewThreshold {
+		warnings = append(warnings, fmt.Sprintf("Rows are unevenly spread over the workers, the busiest one processed %.1fx the average", *stats.rowsSkew))
+	}
+	if stats.singleCopy {
+		infos = append(infos, "Single Copy, the plan below is run by a single process and the leader only gathers its rows")
+	}
+	if node.WorkersLaunched != nil && stats.efficiency != nil {
+		infos = append(infos, fmt.Sprintf("Parallel efficiency is %.0f%%", *stats.efficiency))
+	}
+
+	return warnings, infos, nil
+}
+
+// skew returns nil when there are fewer than 2 values, or when they are all 0
+func skew(values []float64) *float64 {
+	if len(values) < 2 {
+		return nil
+	}
+
+	mean := sum(values) / float64(len(values))
+	if mean == 0 {
+		return nil
+	}
+
+	max := values[0]
+	for _, value := range values {
+		max = math.Max(max, value)
+	}
+
+	ratio := max / mean
+	return &ratio
+}
+
+func sum(values []float64) float64 {
+	total := 0.0
+	for _, value := range values {
+		total += value
+	}
+	return total
+}
@@ -0,0 +1,96 @@
+package pkg
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestNewGatherStats(t *testing.T) {
+	gather := func(nodeType string, planned, launched float64, workers string) string {
+		return fmt.Sprintf(`[{"Plan":{"Node Type":"%v","Total Cost":100,"Plan Rows":300,"Actual Startup Time":1,"Actual Total Time":10,"Actual Rows":300,"Actual Loops":1,`+
+			`"Workers Planned":%v,"Workers Launched":%v,"Single Copy":false,"Plans":[`+
+			`{"Node Type":"Seq Scan","Parent Relationship":"Outer","Parallel Aware":true,"Relation Name":"orders","Total Cost":90,"Plan Rows":100,"Actual Startup Time":0.1,"Actual Total Time":8,"Actual Rows":100,"Actual Loops":3,"Workers":[%v]}]}}]`,
+			nodeType, planned, launched, workers)
+	}
+
+	float := func(value float64) *float64 {
+		return &value
+	}
+
+	tests := []struct {
+		name                    string
+		plan                    string
+		wantLeaderParticipation *float64
+		wantRowsSkew            *float64
+		wantEfficiency          *float64
+		wantWarnings            int
+	}{
+		{
+			name:                    "balanced",
+			plan:                    gather("Gather", 2, 2, `{"Worker Number":0,"Actual Startup Time":0.1,"Actual Total Time":8,"Actual Rows":100,"Actual Loops":1},{"Worker Number":1,"Actual Startup Time":0.1,"Actual Total Time":8,"Actual Rows":100,"Actual Loops":1}`),
+			wantLeaderParticipation: float(100.0 / 3),
+			wantRowsSkew:            float(1),
+			wantEfficiency:          float(100),
+			wantWarnings:            0,
+		},
+		{
+			name:                    "skewed and short of workers",
+			plan:                    gather("Gather Merge", 4, 2, `{"Worker Number":0,"Actual Startup Time":0.1,"Actual Total Time":9,"Actual Rows":180,"Actual Loops":1},{"Worker Number":1,"Actual Startup Time":0.1,"Actual Total Time":3,"Actual Rows":60,"Actual Loops":1}`),
+			wantLeaderParticipation: float(20),
+			wantRowsSkew:            float(1.5),
+			wantEfficiency:          float(50 * 6.0 / 9),
+			wantWarnings:            2,
+		},
+		{
+			// Without VERBOSE the workers of the Sort only report the sort method and space
+			name: "sort workers without rows",
+			plan: `[{"Plan":{"Node Type":"Gather Merge","Total Cost":100,"Plan Rows":300,"Actual Startup Time":1,"Actual Total Time":10,"Actual Rows":300,"Actual Loops":1,"Workers Planned":2,"Workers Launched":2,"Plans":[` +
+				`{"Node Type":"Sort","Parent Relationship":"Outer","Total Cost":95,"Plan Rows":100,"Actual Startup Time":8,"Actual Total Time":9,"Actual Rows":100,"Actual Loops":3,"Sort Key":["id"],"Sort Method":"quicksort","Sort Space Used":30,"Sort Space Type":"Memory",` +
+				`"Workers":[{"Worker Number":0,"Sort Method":"quicksort","Sort Space Used":25,"Sort Space Type":"Memory"},{"Worker Number":1,"Sort Method":"quicksort","Sort Space Used":25,"Sort Space Type":"Memory"}],"Plans":[` +
+				`{"Node Type":"Seq Scan","Parent Relationship":"Outer","Parallel Aware":true,"Relation Name":"orders","Total Cost":90,"Plan Rows":100,"Actual Startup Time":0.1,"Actual Total Time":8,"Actual Rows":100,"Actual Loops":3}]}]}}]`,
+			wantLeaderParticipation: nil,
+			wantRowsSkew:            nil,
+			wantEfficiency:          float(100),
+			wantWarnings:            0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := GetRootNodeFromPlans(tt.plan)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			stats, err := newGatherStats(node)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, value := range []struct {
+				name string
+				got  *float64
+				want *float64
+			}{
+				{"leader participation", stats.leaderParticipation, tt.wantLeaderParticipation},
+				{"rows skew", stats.rowsSkew, tt.wantRowsSkew},
+				{"efficiency", stats.efficiency, tt.wantEfficiency},
+			} {
+				if value.want == nil {
+					if value.got != nil {
+						t.Errorf("%v = %v, want none", value.name, *value.got)
+					}
+				} else if value.got == nil || math.Abs(*value.got-*value.want) > 1e-9 {
+					t.Errorf("%v = %v, want %v", value.name, value.got, *value.want)
+				}
+			}
+
+			warnings, _, err := getGatherInsights(node)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(warnings) != tt.wantWarnings {
+				t.Errorf("warnings = %v, want %v", warnings, tt.wantWarnings)
+			}
+		})
+	}
+}
@@ -0,0 +1,221 @@
+package pkg
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ComputePartitionsStats describes the Append and Merge Append nodes of the plan, nil when there are none
+func (s *StatsGather) ComputePartitionsStats(node *PlanNode) (*PartitionsStats, error) {
+	appends := make([]AppendStats, 0)
+	if err := s.computePartitionsStats(node, &appends); err != nil {
+		return nil, err
+	}
+
+	if len(appends) == 0 {
+		return nil, nil
+	}
+	return &PartitionsStats{Appends: appends}, nil
+}
+
+func (s *StatsGather) computePartitionsStats(node *PlanNode, appends *[]AppendStats) error {
+	if isAppend(node) {
+		stats, err := newAppendStats(node)
+		if err != nil {
+			return err
+		}
+		*appends = append(*appends, stats)
+	}
+
+	for _, subNode := range node.subNodes() {
+		if err := s.computePartitionsStats(subNode, appends); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func isAppend(node *PlanNode) bool {
+	return node.NodeType == APPEND || node.NodeType == MERGE_APPEND
+}
+
+func newAppendStats(node *PlanNode) (AppendStats, error) {
+	stats := AppendStats{
+		Id:         node.NodeId,
+		Operation:  node.NodeType,
+		Partitions: make([]PartitionGroup, 0),
+	}
+
+	if node.Extra[SUBPLANS_REMOVED] != nil {
+		removed, err := floatProperty(node.Path, node.Extra, SUBPLANS_REMOVED)
+		if err != nil {
+			return AppendStats{}, err
+		}
+		stats.SubplansRemoved = int(removed)
+	}
+
+	parents := appendPartitions(node)
+	groups := map[string]*PartitionGroup{}
+	for _, member := range appendMembers(node) {
+		stats.Subplans++
+
+		// Pruned while running, e.g. by the parameters of a Nested Loop
+		if member.ActualLoops != nil && *member.ActualLoops == 0 {
+			stats.NeverExecuted++
+		}
+
+		scan := memberRelation(member)
+		if scan == nil {
+			continue
+		}
+
+		parent := parents[scan]
+		group, ok := groups[parent]
+		if !ok {
+			group = &PartitionGroup{Parent: parent, Partitions: make([]string, 0)}
+			groups[parent] = group
+		}
+		group.Partitions = append(group.Partitions, qualifiedName(scan.Schema, scan.RelationName))
+		group.TotalTime += member.InclusiveDuration
+	}
+	stats.Subplans += stats.SubplansRemoved
+
+	for _, group := range groups {
+		stats.Partitions = append(stats.Partitions, *group)
+	}
+	sort.Slice(stats.Partitions, func(i, j int) bool {
+		return stats.Partitions[i].Parent < stats.Partitions[j].Parent
+	})
+
+	return stats, nil
+}
+
+// appendMembers skips the InitPlans and the SubPlans, they aren't members of the Append
+func appendMembers(node *PlanNode) []*PlanNode {
+	members := make([]*PlanNode, 0, len(node.Plans))
+	for _, child := range node.Plans {
+		if child.ParentRelationship != "InitPlan" && child.ParentRelationship != "SubPlan" {
+			members = append(members, child)
+		}
+	}
+	return members
+}
+
+// memberRelation finds the relation scanned by a member of an Append, the members of a Merge Append are often
+// sorted first
+func memberRelation(member *PlanNode) *PlanNode {
+	for node := member; node != nil; {
+		if node.RelationName != "" {
+			return node
+		}
+		if len(node.Plans) != 1 {
+			return nil
+		}
+		node = node.Plans[0]
+	}
+	return nil
+}
+
+// appendPartitions maps the relations scanned by the members of an Append to the parent table they're inferred to
+// be partitions of. The name of the parent is only guessed from a partition-like suffix shared by several members,
+// or by a member of an Append that pruned subplans, otherwise the scanned relation is its own parent, e.g. for a
+// UNION ALL of tables.
+func appendPartitions(node *PlanNode) map[*PlanNode]string {
+	scans := make([]*PlanNode, 0)
+	members := map[string]int{}
+	for _, member := range appendMembers(node) {
+		if scan := memberRelation(member); scan != nil {
+			scans = append(scans, scan)
+			if parent, ok := inferPartitionParent(scan.RelationName); ok {
+				members[parent]++
+			}
+		}
+	}
+
+	pruned := node.Extra[SUBPLANS_REMOVED] != nil
+	parents := make(map[*PlanNode]string, len(scans))
+	for _, scan := range scans {
+		parent, ok := inferPartitionParent(scan.RelationName)
+		if !ok || (members[parent] < 2 && !pruned) {
+			parent = scan.RelationName
+		}
+		parents[scan] = qualifiedName(scan.Schema, parent)
+	}
+
+	return parents
+}
+
+// The suffixes of the partitions, e.g. _2024, _p1, _y2006m02, _2024q1, _h3 or _default
+var partitionSuffixRegex = regexp.MustCompile(`^(default|(p|part|h|hash|y|q|m|w|d)?\d+|y\d{4}(m\d{1,2}(d\d{1,2})?|q\d|w\d{1,2})|\d{4}(m\d{1,2}|q\d|w\d{1,2}|h\d))$`)
+
+// inferPartitionParent strips the partition suffixes of a name, e.g. measurement_y2006m02, orders_p1 and
+// orders_2024_01 are partitions of measurement and orders. It's false for a name without such a suffix, e.g.
+// user_events isn't a partition of user. The chunks of a hypertable are named after its id, _hyper_1_2_chunk is a
+// chunk of _hyper_1.
+func inferPartitionParent(name string) (string, bool) {
+	if match := chunkNameRegex.FindStringSubmatch(name); match != nil {
+		return fmt.Sprintf("_hyper_%v", match[2]), true
+	}
+
+	parent, partition := name, false
+	for {
+		i := strings.LastIndex(parent, "_")
+		if i <= 0 || !partitionSuffixRegex.MatchString(strings.ToLower(parent[i+1:])) {
+			return parent, partition
+		}
+		parent, partition = parent[:i], true
+	}
+}
+
+func getAppendProperties(node *PlanNode) ([]Property, error) {
+	stats, err := newAppendStats(node)
+	if err != nil {
+		return nil, err
+	}
+
+	props := []Property{
+		{ID: "subplans", Name: "Subplans", Type: "float", ValueFloat: float64(stats.Subplans), Kind: Quantity},
+		{ID: "subplans_removed", Name: SUBPLANS_REMOVED, Type: "float", ValueFloat: float64(stats.SubplansRemoved), Kind: Quantity},
+	}
+	if node.ActualLoops != nil {
+		props = append(props, Property{
+			ID:         "subplans_never_executed",
+			Name:       "Subplans Never Executed",
+			Type:       "float",
+			ValueFloat: float64(stats.NeverExecuted),
+			Kind:       Quantity,
+		})
+	}
+
+	for _, group := range stats.Partitions {
+		if len(group.Partitions) < 2 && group.Partitions[0] == group.Parent {
+			continue
+		}
+		props = append(props, Property{
+			ID:          "partitions",
+			Name:        "Partitions of " + group.Parent,
+			Type:        "string",
+			ValueString: strings.Join(group.Partitions, ", "),
+		})
+	}
+
+	return props, nil
+}
+
+// partitionParents maps the scans of partitions to their inferred parent table
+func partitionParents(node *PlanNode, parents map[*PlanNode]string) {
+	if isAppend(node) {
+		for scan, parent := range appendPartitions(node) {
+			if parent != qualifiedName(scan.Schema, scan.RelationName) {
+				parents[scan] = parent
+			}
+		}
+	}
+
+	for _, subNode := range node.subNodes() {
+		partitionParents(subNode, parents)
+	}
+}
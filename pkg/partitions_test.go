@@ -0,0 +1,134 @@
+package pkg
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestInferPartitionParent(t *testing.T) {
+	tests := []struct {
+		name          string
+		partition     string
+		want          string
+		wantPartition bool
+	}{
+		{name: "range", partition: "measurement_y2006m02", want: "measurement", wantPartition: true},
+		{name: "numbered", partition: "orders_p1", want: "orders", wantPartition: true},
+		{name: "sub partition", partition: "orders_2024_01", want: "orders", wantPartition: true},
+		{name: "quarter", partition: "sales_2024q1", want: "sales", wantPartition: true},
+		{name: "default", partition: "events_default", want: "events", wantPartition: true},
+		{name: "chunk", partition: "_hyper_1_2_chunk", want: "_hyper_1", wantPartition: true},
+		{name: "without suffix", partition: "orders", want: "orders", wantPartition: false},
+		{name: "word suffix", partition: "user_events", want: "user_events", wantPartition: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, partition := inferPartitionParent(tt.partition)
+			if got != tt.want || partition != tt.wantPartition {
+				t.Errorf("inferPartitionParent() = %v, %v, want %v, %v", got, partition, tt.want, tt.wantPartition)
+			}
+		})
+	}
+}
+
+func TestStatsGather_ComputePartitionsStats(t *testing.T) {
+	scan := func(relation string, loops int) string {
+		return fmt.Sprintf(`{"Node Type":"Seq Scan","Parent Relationship":"Member","Relation Name":"%v","Schema":"public","Alias":"%v","Total Cost":10,"Plan Rows":10,`+
+			`"Actual Startup Time":0,"Actual Total Time":%v,"Actual Rows":10,"Actual Loops":%v}`, relation, relation, 2*loops, loops)
+	}
+
+	tests := []struct {
+		name            string
+		plan            string
+		want            *PartitionsStats
+		wantTables      map[string]string
+		wantPartitioned []PartitionedTableStats
+	}{
+		{
+			name: "pruned partitions",
+			plan: `[{"Plan":{"Node Type":"Append","Total Cost":30,"Plan Rows":30,"Actual Startup Time":0,"Actual Total Time":5,"Actual Rows":20,"Actual Loops":1,"Subplans Removed":9,"Plans":[` +
+				scan("orders_2024_01", 1) + `,` + scan("orders_2024_02", 1) + `,` + scan("orders_2024_03", 0) + `]}}]`,
+			want: &PartitionsStats{Appends: []AppendStats{{
+				Operation:       "Append",
+				Subplans:        12,
+				SubplansRemoved: 9,
+				NeverExecuted:   1,
+				Partitions: []PartitionGroup{
+					{Parent: "public.orders", Partitions: []string{"public.orders_2024_01", "public.orders_2024_02", "public.orders_2024_03"}, TotalTime: 4},
+				},
+			}}},
+			wantTables: map[string]string{
+				"public.orders_2024_01": "public.orders",
+				"public.orders_2024_02": "public.orders",
+				"public.orders_2024_03": "public.orders",
+			},
+			wantPartitioned: []PartitionedTableStats{
+				{Name: "public.orders", Partitions: []string{"public.orders_2024_01", "public.orders_2024_02", "public.orders_2024_03"}, TotalTime: 4},
+			},
+		},
+		{
+			name: "union of tables sharing a prefix",
+			plan: `[{"Plan":{"Node Type":"Append","Total Cost":20,"Plan Rows":20,"Actual Startup Time":0,"Actual Total Time":5,"Actual Rows":20,"Actual Loops":1,"Plans":[` +
+				scan("user_events", 1) + `,` + scan("user_sessions", 1) + `,` + scan("sessions", 1) + `]}}]`,
+			want: &PartitionsStats{Appends: []AppendStats{{
+				Operation: "Append",
+				Subplans:  3,
+				Partitions: []PartitionGroup{
+					{Parent: "public.sessions", Partitions: []string{"public.sessions"}, TotalTime: 2},
+					{Parent: "public.user_events", Partitions: []string{"public.user_events"}, TotalTime: 2},
+					{Parent: "public.user_sessions", Partitions: []string{"public.user_sessions"}, TotalTime: 2},
+				},
+			}}},
+			wantTables: map[string]string{
+				"public.sessions":      "",
+				"public.user_events":   "",
+				"public.user_sessions": "",
+			},
+			wantPartitioned: []PartitionedTableStats{},
+		},
+		{
+			name: "without append",
+			plan: `[{"Plan":{"Node Type":"Seq Scan","Relation Name":"orders","Total Cost":10,"Plan Rows":10}}]`,
+			want: nil,
+			wantTables: map[string]string{
+				"orders": "",
+			},
+			wantPartitioned: []PartitionedTableStats{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := GetRootNodeFromPlans(tt.plan)
+			if err != nil {
+				t.Fatal(err)
+			}
+			NewPlanEnricher().SetIDMode(IDModeDeterministic).AnalyzePlan(node)
+
+			got, err := NewStatsGather().ComputePartitionsStats(node)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != nil {
+				for i := range got.Appends {
+					got.Appends[i].Id = ""
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ComputePartitionsStats() = %+v, want %+v", got, tt.want)
+			}
+
+			tablesStats := NewStatsGather().ComputeTablesStats(node)
+			tables := map[string]string{}
+			for _, table := range tablesStats.Tables {
+				tables[table.Name] = table.Parent
+			}
+			if !reflect.DeepEqual(tables, tt.wantTables) {
+				t.Errorf("ComputeTablesStats() = %v, want %v", tables, tt.wantTables)
+			}
+			if !reflect.DeepEqual(tablesStats.Partitioned, tt.wantPartitioned) {
+				t.Errorf("ComputeTablesStats() partitioned = %+v, want %+v", tablesStats.Partitioned, tt.wantPartitioned)
+			}
+		})
+	}
+}
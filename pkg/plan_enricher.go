@@ -6,227 +6,235 @@ import (
 	"strings"
 )
 
+type IDMode = string
+
+const (
+	// IDModeRandom assigns a new uuid to every node, each analysis of a plan gets different identifiers
+	IDModeRandom = IDMode("random")
+	// IDModeDeterministic derives the identifier of a node from its path in the tree and its fingerprint,
+	// analysing the same plan twice gets the same identifiers
+	IDModeDeterministic = IDMode("deterministic")
+)
+
+// Namespace of the deterministic identifiers, they are name based uuids (version 5)
+var nodeIdNamespace = uuid.MustParse("5b8f2c1e-6f0d-4a63-9a43-2f1f0c6a7d52")
+
 type PlanEnricher struct {
-	ctes            map[string]Node
+	ctes            map[string]*PlanNode
 	containsBuffers bool
+	idMode          IDMode
 }
 
 func NewPlanEnricher() *PlanEnricher {
 	return &PlanEnricher{
-		ctes:            map[string]Node{},
+		ctes:            map[string]*PlanNode{},
 		containsBuffers: false,
+		idMode:          IDModeRandom,
 	}
 }
 
-func (ps *PlanEnricher) AnalyzePlan(rootNode Node) {
+// SetIDMode chooses how the node identifiers are generated, IDModeRandom by default
+func (ps *PlanEnricher) SetIDMode(mode IDMode) *PlanEnricher {
+	ps.idMode = mode
+	return ps
+}
+
+// AnalyzePlan computes the derived values of every node, the values decoded from the plan are left untouched
+func (ps *PlanEnricher) AnalyzePlan(rootNode *PlanNode) {
 	ps.processNode(rootNode)
-	rootNode[CTES] = ps.ctes
+	rootNode.CTEs = ps.ctes
 }
 
-func (ps *PlanEnricher) checkBuffers(node Node) {
-	if node[SHARED_HIT_BLOCKS] != nil {
+func (ps *PlanEnricher) checkBuffers(node *PlanNode) {
+	if node.Buffers != nil {
 		ps.containsBuffers = true
-		node[DOES_CONTAIN_BUFFERS] = true
+		node.DoesContainBuffers = true
 		return
 	}
 
 	ps.containsBuffers = false
-	node[DOES_CONTAIN_BUFFERS] = false
+	node.DoesContainBuffers = false
 }
 
-func (ps *PlanEnricher) processNode(node Node) {
-	node[NODE_ID] = uuid.New().String()
-
+func (ps *PlanEnricher) processNode(node *PlanNode) {
 	ps.checkBuffers(node)
 	ps.calculatePlannerEstimate(node)
 
-	// If the key is "Plans", then iterated over all the sub nodes
-	if node[PLANS_PROP] != nil {
-		for _, child := range node[PLANS_PROP].([]interface{}) {
-			childNode := child.(Node)
-
-			// Add workers planned info to parallel nodes (ie. Gather children)
-			if !IsCTE(childNode) && childNode[PARENT_RELATIONSHIP] != "InitPlan" && childNode[PARENT_RELATIONSHIP] != "SubPlan" {
-				if node[WORKERS_PLANNED] != nil {
-					childNode[WORKERS_PLANNED_BY_GATHER] = node[WORKERS_PLANNED]
-				} else {
-					childNode[WORKERS_PLANNED_BY_GATHER] = node[WORKERS_PLANNED_BY_GATHER]
-				}
-
-				if node[WORKERS_LAUNCHED] != nil {
-					childNode[WORKERS_LAUNCHED] = node[WORKERS_LAUNCHED]
-				}
+	for _, childNode := range node.Plans {
+		// Add workers planned info to parallel nodes (ie. Gather children)
+		if !IsCTE(childNode) && childNode.ParentRelationship != "InitPlan" && childNode.ParentRelationship != "SubPlan" {
+			if node.WorkersPlanned != nil {
+				childNode.WorkersPlannedByGather = node.WorkersPlanned
+			} else {
+				childNode.WorkersPlannedByGather = node.WorkersPlannedByGather
 			}
 
-			// Plans belonging to CTEs are not found as direct child of CTEs nodes,
-			// { "Node Type": "CTE Scan" }
-			// Instead they just appears as child nodes of root, thus they have to be
-			// grouped and put back in the root node
-			if IsCTE(childNode) {
-				subPlanName := strings.ReplaceAll(childNode[SUBPLAN_NAME].(string), "CTE ", "")
-				childNode[IS_CTE_ROOT] = "true"
-				childNode[CTE_SUBPLAN_OF] = subPlanName
-				ps.ctes[subPlanName] = childNode
-			}
-			if node[CTE_SUBPLAN_OF] != nil {
-				childNode[CTE_SUBPLAN_OF] = node[CTE_SUBPLAN_OF]
+			if node.WorkersLaunched != nil {
+				childNode.WorkersLaunchedByGather = node.WorkersLaunched
+			} else {
+				childNode.WorkersLaunchedByGather = node.WorkersLaunchedByGather
 			}
+		}
 
-			ps.processNode(childNode)
+		// Plans belonging to CTEs are not found as direct child of CTEs nodes,
+		// { "Node Type": "CTE Scan" }
+		// Instead they just appears as child nodes of root, thus they have to be
+		// grouped and put back in the root node
+		if IsCTE(childNode) {
+			subPlanName := strings.ReplaceAll(childNode.SubplanName, "CTE ", "")
+			childNode.IsCTERoot = true
+			childNode.CTESubplanOf = subPlanName
+			ps.ctes[subPlanName] = childNode
+		}
+		if node.CTESubplanOf != "" {
+			childNode.CTESubplanOf = node.CTESubplanOf
 		}
+
+		ps.processNode(childNode)
+	}
+
+	// The plans of the tasks are run by the worker nodes, their times don't add up to the ones of the coordinator
+	for _, task := range node.Tasks {
+		NewPlanEnricher().SetIDMode(ps.idMode).AnalyzePlan(task.Plan)
 	}
 
 	ps.calculateActuals(node)
 	ps.calculateExclusive(node)
+	node.Fingerprint = computeNodeFingerprint(node)
+	node.NodeId = ps.nodeId(node)
+}
+
+// nodeId must be called once the fingerprint is known
+func (ps *PlanEnricher) nodeId(node *PlanNode) string {
+	if ps.idMode == IDModeDeterministic {
+		return uuid.NewSHA1(nodeIdNamespace, []byte(node.Path+"\n"+node.Fingerprint)).String()
+	}
+	return uuid.New().String()
 }
 
-func (ps *PlanEnricher) calculatePlannerEstimate(node Node) {
-	if node[ACTUAL_ROWS] != nil && node[PLAN_ROWS] != nil {
-		node[PLANNER_ESTIMATE_DIRECTION] = EstimateDirectionNone
-		node[PLANNER_ESTIMATE_FACTOR] = node[PLAN_ROWS].(float64) / node[ACTUAL_ROWS].(float64)
+func (ps *PlanEnricher) calculatePlannerEstimate(node *PlanNode) {
+	node.PlannerEstimateDirection = EstimateDirectionNone
+	node.PlannerEstimateFactor = 0
 
-		if node[ACTUAL_ROWS].(float64) < node[PLAN_ROWS].(float64) {
-			node[PLANNER_ESTIMATE_DIRECTION] = EstimateDirectionOver
+	if node.ActualRows != nil {
+		actualRows := *node.ActualRows
+		node.PlannerEstimateFactor = node.PlanRows / actualRows
+
+		if actualRows < node.PlanRows {
+			node.PlannerEstimateDirection = EstimateDirectionOver
 		}
 
-		if node[ACTUAL_ROWS].(float64) > node[PLAN_ROWS].(float64) {
-			node[PLANNER_ESTIMATE_FACTOR] = node[ACTUAL_ROWS].(float64) / node[PLAN_ROWS].(float64)
-			node[PLANNER_ESTIMATE_DIRECTION] = EstimateDirectionUnder
+		if actualRows > node.PlanRows {
+			node.PlannerEstimateFactor = actualRows / node.PlanRows
+			node.PlannerEstimateDirection = EstimateDirectionUnder
 		}
-	} else {
-		node[PLANNER_ESTIMATE_DIRECTION] = EstimateDirectionNone
-		node[PLANNER_ESTIMATE_FACTOR] = float64(0)
-	}
-
-	// There is the possibility that the calculation of PLANNER_ESTIMATE_FACTOR will yield Inf or NaN:
-	//  var zero interface{}
-	//	zero = 0.0
-	//	inf := 1 / zero.(float64)
-	// This will be equal to +Inf
-	if math.IsInf(node[PLANNER_ESTIMATE_FACTOR].(float64), 0) {
-		node[PLANNER_ESTIMATE_FACTOR] = float64(0)
-		return
 	}
-	if math.IsNaN(node[PLANNER_ESTIMATE_FACTOR].(float64)) {
-		node[PLANNER_ESTIMATE_FACTOR] = float64(0)
+
+	// There is the possibility that the calculation of the factor will yield Inf or NaN when one of the rows is 0
+	if math.IsInf(node.PlannerEstimateFactor, 0) || math.IsNaN(node.PlannerEstimateFactor) {
+		node.PlannerEstimateFactor = 0
 	}
 }
 
-func (ps *PlanEnricher) calculateActuals(node Node) {
-	if node[ACTUAL_TOTAL_TIME] != nil {
+// calculateActuals keeps the times reported by Postgres as they are, the adjusted ones are written to the
+// computed fields, thus analysing the same tree again gives the same result
+func (ps *PlanEnricher) calculateActuals(node *PlanNode) {
+	node.InclusiveDuration = 0.0
+	node.StartupDuration = 0.0
+	node.ExclusiveDuration = 0.0
+
+	if node.ActualTotalTime != nil {
 		// since time is reported for an individual loop, actual duration must be adjusted by number of loops
 		// number of workers is also taken into account
 		workers := ps.getWorkers(node)
-		node[ACTUAL_TOTAL_TIME] = (node[ACTUAL_TOTAL_TIME].(float64) * node[ACTUAL_LOOPS].(float64)) / workers
-		if node[ACTUAL_STARTUP_TIME] != nil {
-			node[ACTUAL_STARTUP_TIME] = (node[ACTUAL_STARTUP_TIME].(float64) * node[ACTUAL_LOOPS].(float64)) / workers
-		} else {
-			node[ACTUAL_STARTUP_TIME] = 0.0
-		}
-		node[EXCLUSIVE_DURATION] = node[ACTUAL_TOTAL_TIME]
+		loops := floatValue(node.ActualLoops)
 
-		duration := (node[EXCLUSIVE_DURATION].(float64)) - ps.childrenDuration(node, 0)
-		if duration > 0 {
-			node[EXCLUSIVE_DURATION] = duration
-		} else {
-			node[EXCLUSIVE_DURATION] = 0.0
+		node.InclusiveDuration = (*node.ActualTotalTime * loops) / workers
+		node.StartupDuration = (floatValue(node.ActualStartupTime) * loops) / workers
+
+		if duration := node.InclusiveDuration - ps.childrenDuration(node, 0); duration > 0 {
+			node.ExclusiveDuration = duration
 		}
 	}
-	node[ACTUAL_DURATION] = node[ACTUAL_TOTAL_TIME]
-	node[ACTUAL_COST_PROP] = node[TOTAL_COST]
 
-	if node[FILTER] == nil {
-		node[FILTER] = ""
+	node.ActualRowsRevised = ps.reviseRows(node, node.ActualRows)
+	node.PlanRowsRevised = ps.reviseRows(node, &node.PlanRows)
+	node.RowsRemovedByFilterRevised = ps.reviseRows(node, node.RowsRemovedByFilter)
+	node.RowsRemovedByJoinFilterRevised = ps.reviseRows(node, node.RowsRemovedByJoinFilter)
+	node.RowsRemovedByRecheckRevised = ps.reviseRows(node, node.RowsRemovedByRecheck)
+}
+
+// reviseRows returns the rows of all the loops, or of all the workers for parallel nodes
+func (ps *PlanEnricher) reviseRows(node *PlanNode, rows *float64) float64 {
+	if rows == nil {
+		return 0.0
 	}
 
-	for _, name := range []string{
-		ACTUAL_ROWS,
-		PLAN_ROWS,
-		ROWS_REMOVED_BY_FILTER,
-		ROWS_REMOVED_BY_JOIN_FILTER,
-	} {
-		if node[name] != nil {
-			loops := 1.0
-			if node[ACTUAL_LOOPS] != nil {
-				loops = node[ACTUAL_LOOPS].(float64)
-			}
+	loops := 1.0
+	if node.ActualLoops != nil {
+		loops = *node.ActualLoops
+	}
 
-			if ps.getWorkers(node) > 1 {
-				node[name+REVISED] = ConvertToFloat64(node[name]) * ps.getWorkers(node)
-			} else {
-				node[name+REVISED] = ConvertToFloat64(node[name]) * loops
-			}
-		} else {
-			node[name+REVISED] = 0.0
-		}
+	if ps.getWorkers(node) > 1 {
+		return *rows * ps.getWorkers(node)
 	}
+	return *rows * loops
 }
 
-func (ps *PlanEnricher) getWorkers(node Node) float64 {
+func (ps *PlanEnricher) getWorkers(node *PlanNode) float64 {
 	workers := 1.0
-	if node[WORKERS_PLANNED_BY_GATHER] != nil {
-		workers = node[WORKERS_PLANNED_BY_GATHER].(float64) + 1.0
+	if node.WorkersPlannedByGather != nil {
+		workers = *node.WorkersPlannedByGather + 1.0
 	}
 	return workers
 }
 
 // Any node reports total of what it used itself, plus all that its sub-nodes used
 // https://www.depesz.com/2021/06/20/explaining-the-unexplainable-part-6-buffers/
-func (ps *PlanEnricher) calculateExclusive(node Node) {
-	properties := []string{
-		SHARED_HIT_BLOCKS,
-		SHARED_READ_BLOCKS,
-		SHARED_DIRTIED_BLOCKS,
-		SHARED_WRITTEN_BLOCKS,
-		TEMP_READ_BLOCKS,
-		TEMP_WRITTEN_BLOCKS,
-		LOCAL_HIT_BLOCKS,
-		LOCAL_READ_BLOCKS,
-		LOCAL_DIRTIED_BLOCKS,
-		LOCAL_WRITTEN_BLOCKS,
-		IO_READ_TIME,
-		IO_WRITE_TIME,
-		TOTAL_COST,
-	}
-
-	for _, property := range properties {
-		if node[property] == nil {
-			node[property] = 0.0
-			continue
+func (ps *PlanEnricher) calculateExclusive(node *PlanNode) {
+	totalCost, ioReadTime, ioWriteTime, buffers, wal := 0.0, 0.0, 0.0, NodeBuffers{}, NodeWAL{}
+	for _, sn := range node.Plans {
+		totalCost += sn.TotalCost
+		ioReadTime += sn.IOReadTime
+		ioWriteTime += sn.IOWriteTime
+
+		if sn.WAL != nil {
+			for _, property := range walProperties {
+				*wal.field(property) += *sn.WAL.field(property)
+			}
 		}
 
-		sum := 0.0
-		exclusiveProp := EXCLUSIVE + property
-		node[exclusiveProp] = node[property]
-
-		if node[PLANS_PROP] == nil {
+		if sn.Buffers == nil {
 			continue
 		}
-
-		for _, subNode := range node[PLANS_PROP].([]interface{}) {
-			sn := subNode.(Node)
-			if sn[property] != nil {
-				sum += sn[property].(float64)
-			}
+		for _, property := range bufferProperties {
+			*buffers.field(property) += *sn.Buffers.field(property)
 		}
+	}
 
-		node[exclusiveProp] = node[property].(float64) - sum
+	node.ExclusiveTotalCost = node.TotalCost - totalCost
+	node.ExclusiveIOReadTime = node.IOReadTime - ioReadTime
+	node.ExclusiveIOWriteTime = node.IOWriteTime - ioWriteTime
+
+	node.ExclusiveWAL = NodeWAL{}
+	if node.WAL != nil {
+		for _, property := range walProperties {
+			*node.ExclusiveWAL.field(property) = *node.WAL.field(property) - *wal.field(property)
+		}
 	}
-}
 
-func (ps *PlanEnricher) childrenDuration(node Node, duration float64) float64 {
-	if node[PLANS_PROP] == nil {
-		return duration
+	if node.Buffers == nil {
+		return
+	}
+	for _, property := range bufferProperties {
+		*node.ExclusiveBuffers.field(property) = *node.Buffers.field(property) - *buffers.field(property)
 	}
+}
 
-	for _, subNode := range node[PLANS_PROP].([]interface{}) {
-		sn := subNode.(Node)
-		if sn[PARENT_RELATIONSHIP] != "InitPlan" {
-			if sn[EXCLUSIVE_DURATION] == nil {
-				duration += 0.0
-			} else {
-				duration += sn[EXCLUSIVE_DURATION].(float64)
-			}
+func (ps *PlanEnricher) childrenDuration(node *PlanNode, duration float64) float64 {
+	for _, sn := range node.Plans {
+		if sn.ParentRelationship != "InitPlan" {
+			duration += sn.ExclusiveDuration
 			duration = ps.childrenDuration(sn, duration)
 		}
 	}
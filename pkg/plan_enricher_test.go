@@ -1,10 +1,13 @@
 package pkg
 
-import "testing"
+import (
+	"math"
+	"testing"
+)
 
 func TestPlanEnricher_AnalyzePlan(t *testing.T) {
 	type fields struct {
-		ctes            map[string]Node
+		ctes            map[string]*PlanNode
 		containsBuffers bool
 	}
 	type args struct {
@@ -40,3 +43,89 @@ func TestPlanEnricher_AnalyzePlan(t *testing.T) {
 		})
 	}
 }
+
+func TestPlanEnricher_AnalyzePlan_Idempotent(t *testing.T) {
+	tests := []struct {
+		name          string
+		plan          string
+		wantRaw       float64
+		wantInclusive float64
+	}{
+		{
+			name:          "loops",
+			plan:          `[{"Plan":{"Node Type":"Nested Loop","Total Cost":10,"Plan Rows":3,"Actual Startup Time":0.01,"Actual Total Time":0.2,"Actual Rows":3,"Actual Loops":1,"Plans":[{"Node Type":"Seq Scan","Parent Relationship":"Outer","Total Cost":1,"Plan Rows":3,"Actual Startup Time":0.01,"Actual Total Time":0.02,"Actual Rows":3,"Actual Loops":1},{"Node Type":"Index Scan","Parent Relationship":"Inner","Total Cost":1,"Plan Rows":1,"Actual Startup Time":0.01,"Actual Total Time":0.05,"Actual Rows":1,"Actual Loops":3}]}}]`,
+			wantRaw:       0.05,
+			wantInclusive: 0.15,
+		},
+		{
+			name:          "workers",
+			plan:          `[{"Plan":{"Node Type":"Gather","Total Cost":10,"Plan Rows":3,"Workers Planned":2,"Workers Launched":2,"Actual Startup Time":0.01,"Actual Total Time":0.5,"Actual Rows":3,"Actual Loops":1,"Plans":[{"Node Type":"Seq Scan","Parent Relationship":"Outer","Total Cost":1,"Plan Rows":3,"Actual Startup Time":0.01,"Actual Total Time":0.3,"Actual Rows":1,"Actual Loops":3},{"Node Type":"Seq Scan","Parent Relationship":"Outer","Total Cost":1,"Plan Rows":3,"Actual Startup Time":0.01,"Actual Total Time":0.3,"Actual Rows":1,"Actual Loops":3}]}}]`,
+			wantRaw:       0.3,
+			wantInclusive: 0.3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := GetRootNodeFromPlans(tt.plan)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for run := 1; run <= 2; run++ {
+				NewPlanEnricher().AnalyzePlan(node)
+
+				child := node.Plans[1]
+				if *child.ActualTotalTime != tt.wantRaw {
+					t.Errorf("run %v: Actual Total Time = %v, want the raw %v", run, *child.ActualTotalTime, tt.wantRaw)
+				}
+				if math.Abs(child.InclusiveDuration-tt.wantInclusive) > 1e-9 {
+					t.Errorf("run %v: inclusive duration = %v, want %v", run, child.InclusiveDuration, tt.wantInclusive)
+				}
+			}
+		})
+	}
+}
+
+func TestPlanEnricher_AnalyzePlan_WAL(t *testing.T) {
+	tests := []struct {
+		name      string
+		plan      string
+		wantRoot  NodeWAL
+		wantChild NodeWAL
+	}{
+		{
+			name:      "insert",
+			plan:      `[{"Plan":{"Node Type":"ModifyTable","Operation":"Insert","Total Cost":10,"Plan Rows":3,"WAL Records":12,"WAL FPI":2,"WAL Bytes":2048,"Plans":[{"Node Type":"Seq Scan","Total Cost":5,"Plan Rows":3,"WAL Records":2,"WAL FPI":0,"WAL Bytes":300}]}}]`,
+			wantRoot:  NodeWAL{Records: 10, Bytes: 1748, FPI: 2},
+			wantChild: NodeWAL{Records: 2, Bytes: 300, FPI: 0},
+		},
+		{
+			name:      "without wal",
+			plan:      `[{"Plan":{"Node Type":"Limit","Total Cost":10,"Plan Rows":3,"Plans":[{"Node Type":"Seq Scan","Total Cost":5,"Plan Rows":3}]}}]`,
+			wantRoot:  NodeWAL{},
+			wantChild: NodeWAL{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := GetRootNodeFromPlans(tt.plan)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			NewPlanEnricher().AnalyzePlan(node)
+
+			if node.ExclusiveWAL != tt.wantRoot {
+				t.Errorf("root exclusive WAL = %+v, want %+v", node.ExclusiveWAL, tt.wantRoot)
+			}
+			if node.Plans[0].ExclusiveWAL != tt.wantChild {
+				t.Errorf("child exclusive WAL = %+v, want %+v", node.Plans[0].ExclusiveWAL, tt.wantChild)
+			}
+
+			stats := NewStatsGather().ComputeStats(node)
+			if node.WAL != nil && (stats.WALRecords != node.WAL.Records || stats.WALBytes != node.WAL.Bytes || stats.WALFPI != node.WAL.FPI) {
+				t.Errorf("stats WAL = %v/%v/%v, want the root node WAL %+v", stats.WALRecords, stats.WALBytes, stats.WALFPI, *node.WAL)
+			}
+		})
+	}
+}
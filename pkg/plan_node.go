@@ -0,0 +1,522 @@
+package pkg
+
+import "sort"
+
+// PlanNode is a node of the plan tree. The properties used by the pipeline are typed, the others, mostly the node type
+// specific ones, are kept in Extra as they have been decoded from the plan.
+type PlanNode struct {
+	NodeType           string
+	ParentRelationship string
+	SubplanName        string
+	JoinType           string
+	ParallelAware      bool
+
+	RelationName   string
+	Schema         string
+	Alias          string
+	IndexName      string
+	CTEName        string
+	FunctionName   string
+	Filter         string
+	JoinFilter     string
+	IndexCondition string
+
+	// Only reported with the VERBOSE option
+	Output []string
+
+	StartupCost float64
+	TotalCost   float64
+	PlanRows    float64
+	PlanWidth   float64
+
+	// Only reported by EXPLAIN ANALYZE, the times are averaged over the loops and the workers
+	ActualStartupTime       *float64
+	ActualTotalTime         *float64
+	ActualRows              *float64
+	ActualLoops             *float64
+	RowsRemovedByFilter     *float64
+	RowsRemovedByJoinFilter *float64
+	RowsRemovedByRecheck    *float64
+
+	WorkersPlanned  *float64
+	WorkersLaunched *float64
+	Workers         []Node
+
+	// Only reported with the BUFFERS option
+	Buffers     *NodeBuffers
+	IOReadTime  float64
+	IOWriteTime float64
+
+	// Only reported with the WAL option
+	WAL *NodeWAL
+
+	Plans []*PlanNode
+	Extra Node
+
+	// Only reported by Citus, the plans run by the worker nodes for the tasks of a distributed query
+	Tasks []*RemoteTask
+
+	// Set on all the nodes of the plan of a task, nil for the nodes run by the coordinator
+	Task *TaskInfo
+
+	// Position of the node in the tree, e.g. Plans[0].Plans[2], empty for the root node
+	Path string
+
+	// Computed by the PlanEnricher
+	NodeId                         string
+	Fingerprint                    string
+	CTEs                           map[string]*PlanNode
+	IsCTERoot                      bool
+	CTESubplanOf                   string
+	WorkersPlannedByGather         *float64
+	WorkersLaunchedByGather        *float64
+	DoesContainBuffers             bool
+	PlannerEstimateFactor          float64
+	PlannerEstimateDirection       string
+	InclusiveDuration              float64
+	StartupDuration                float64
+	ExclusiveDuration              float64
+	ExclusiveTotalCost             float64
+	ExclusiveBuffers               NodeBuffers
+	ExclusiveIOReadTime            float64
+	ExclusiveIOWriteTime           float64
+	ExclusiveWAL                   NodeWAL
+	ActualRowsRevised              float64
+	PlanRowsRevised                float64
+	RowsRemovedByFilterRevised     float64
+	RowsRemovedByJoinFilterRevised float64
+	RowsRemovedByRecheckRevised    float64
+
+	// Computed by the StatsGather
+	IsSlowest   bool
+	IsLargest   bool
+	IsCostliest bool
+}
+
+type NodeBuffers struct {
+	SharedHit     float64
+	SharedRead    float64
+	SharedDirtied float64
+	SharedWritten float64
+	LocalHit      float64
+	LocalRead     float64
+	LocalDirtied  float64
+	LocalWritten  float64
+	TempRead      float64
+	TempWritten   float64
+}
+
+type NodeWAL struct {
+	Records float64
+	Bytes   float64
+	FPI     float64
+}
+
+var walProperties = []string{
+	WAL_RECORDS,
+	WAL_BYTES,
+	WAL_FPI,
+}
+
+var bufferProperties = []string{
+	SHARED_HIT_BLOCKS,
+	SHARED_READ_BLOCKS,
+	SHARED_DIRTIED_BLOCKS,
+	SHARED_WRITTEN_BLOCKS,
+	TEMP_READ_BLOCKS,
+	TEMP_WRITTEN_BLOCKS,
+	LOCAL_HIT_BLOCKS,
+	LOCAL_READ_BLOCKS,
+	LOCAL_DIRTIED_BLOCKS,
+	LOCAL_WRITTEN_BLOCKS,
+}
+
+// NewPlanNode decodes a node, and all its sub nodes, as it has been unmarshalled from a FORMAT JSON plan.
+// A property that doesn't have the expected type is reported with a *PlanError.
+func NewPlanNode(raw Node) (*PlanNode, error) {
+	return newPlanNode(raw, "")
+}
+
+func newPlanNode(raw Node, path string) (*PlanNode, error) {
+	node := &PlanNode{
+		Extra: Node{},
+		Path:  path,
+	}
+
+	// Sorted, the same malformed plan always reports the same error
+	keys := make([]string, 0, len(raw))
+	for key := range raw {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if err := node.decode(raw, key); err != nil {
+			return nil, err
+		}
+	}
+
+	return node, nil
+}
+
+func (n *PlanNode) decode(raw Node, key string) error {
+	var err error
+
+	switch key {
+	case NODE_TYPE:
+		n.NodeType, err = stringProperty(n.Path, raw, key)
+	case PARENT_RELATIONSHIP:
+		n.ParentRelationship, err = stringProperty(n.Path, raw, key)
+	case SUBPLAN_NAME:
+		n.SubplanName, err = stringProperty(n.Path, raw, key)
+	case JOIN_TYPE:
+		n.JoinType, err = stringProperty(n.Path, raw, key)
+	case PARALLEL_AWARE:
+		n.ParallelAware, err = boolProperty(n.Path, raw, key)
+	case RELATION_NAME:
+		n.RelationName, err = stringProperty(n.Path, raw, key)
+	case SCHEMA:
+		n.Schema, err = stringProperty(n.Path, raw, key)
+	case ALIAS:
+		n.Alias, err = stringProperty(n.Path, raw, key)
+	case INDEX_NAME:
+		n.IndexName, err = stringProperty(n.Path, raw, key)
+	case CTE_NAME:
+		n.CTEName, err = stringProperty(n.Path, raw, key)
+	case FUNCTION_NAME:
+		n.FunctionName, err = stringProperty(n.Path, raw, key)
+	case FILTER:
+		n.Filter, err = stringProperty(n.Path, raw, key)
+	case JOIN_FILTER:
+		n.JoinFilter, err = stringProperty(n.Path, raw, key)
+	case INDEX_CONDITION:
+		n.IndexCondition, err = stringProperty(n.Path, raw, key)
+	case OUTPUT:
+		n.Output, err = stringsProperty(n.Path, raw, key)
+	case STARTUP_COST:
+		n.StartupCost, err = floatProperty(n.Path, raw, key)
+	case TOTAL_COST:
+		n.TotalCost, err = floatProperty(n.Path, raw, key)
+	case PLAN_ROWS:
+		n.PlanRows, err = floatProperty(n.Path, raw, key)
+	case PLAN_WIDTH:
+		n.PlanWidth, err = floatProperty(n.Path, raw, key)
+	case ACTUAL_STARTUP_TIME:
+		n.ActualStartupTime, err = optionalFloatProperty(n.Path, raw, key)
+	case ACTUAL_TOTAL_TIME:
+		n.ActualTotalTime, err = optionalFloatProperty(n.Path, raw, key)
+	case ACTUAL_ROWS:
+		n.ActualRows, err = optionalFloatProperty(n.Path, raw, key)
+	case ACTUAL_LOOPS:
+		n.ActualLoops, err = optionalFloatProperty(n.Path, raw, key)
+	case ROWS_REMOVED_BY_FILTER:
+		n.RowsRemovedByFilter, err = optionalFloatProperty(n.Path, raw, key)
+	case ROWS_REMOVED_BY_JOIN_FILTER:
+		n.RowsRemovedByJoinFilter, err = optionalFloatProperty(n.Path, raw, key)
+	case ROWS_REMOVED_BY_RECHECK:
+		n.RowsRemovedByRecheck, err = optionalFloatProperty(n.Path, raw, key)
+	case WORKERS_PLANNED:
+		n.WorkersPlanned, err = optionalFloatProperty(n.Path, raw, key)
+	case WORKERS_LAUNCHED:
+		n.WorkersLaunched, err = optionalFloatProperty(n.Path, raw, key)
+	case WORKERS:
+		n.Workers, err = decodeWorkers(n.Path, raw[key])
+	case IO_READ_TIME, SHARED_IO_READ_TIME, LOCAL_IO_READ_TIME, TEMP_IO_READ_TIME:
+		err = n.decodeIOTime(raw, key, &n.IOReadTime)
+	case IO_WRITE_TIME, SHARED_IO_WRITE_TIME, LOCAL_IO_WRITE_TIME, TEMP_IO_WRITE_TIME:
+		err = n.decodeIOTime(raw, key, &n.IOWriteTime)
+	case PLANS_PROP:
+		// The subplans of a distributed query have already been decoded, the keys are sorted
+		subplans := n.Plans
+		if n.Plans, err = decodePlans(n.Path, raw[key]); err == nil && len(subplans) > 0 {
+			n.Plans = append(subplans, n.Plans...)
+		}
+	case DISTRIBUTED_QUERY:
+		err = n.decodeDistributedQuery(raw[key])
+	case HEAP_BLOCKS:
+		err = n.decodeHeapBlocks(raw)
+	default:
+		if n.Buffers == nil && isBufferProperty(key) {
+			n.Buffers = &NodeBuffers{}
+		}
+		if n.WAL == nil && isWALProperty(key) {
+			n.WAL = &NodeWAL{}
+		}
+		if field := n.Buffers.field(key); field != nil {
+			*field, err = floatProperty(n.Path, raw, key)
+		} else if field := n.WAL.field(key); field != nil {
+			*field, err = floatProperty(n.Path, raw, key)
+		} else {
+			n.Extra[key] = raw[key]
+		}
+	}
+
+	return err
+}
+
+// decodeHeapBlocks splits the "exact=10 lossy=30" form of the Heap Blocks into the Exact and the Lossy Heap Blocks,
+// unless the plan reports them too
+func (n *PlanNode) decodeHeapBlocks(raw Node) error {
+	heapBlocks, err := stringProperty(n.Path, raw, HEAP_BLOCKS)
+	if err != nil {
+		return err
+	}
+	n.Extra[HEAP_BLOCKS] = heapBlocks
+
+	if raw[EXACT_HEAP_BLOCKS] != nil || raw[LOSSY_HEAP_BLOCKS] != nil {
+		return nil
+	}
+
+	blocks := Node{}
+	parseTextHeapBlocks(blocks, heapBlocks)
+	for _, key := range []string{EXACT_HEAP_BLOCKS, LOSSY_HEAP_BLOCKS} {
+		if blocks[key] == nil {
+			continue
+		}
+		if _, err := floatProperty(n.Path, blocks, key); err != nil {
+			return newPlanError(n.Path, HEAP_BLOCKS, "exact=N lossy=N", heapBlocks)
+		}
+		n.Extra[key] = blocks[key]
+	}
+	return nil
+}
+
+// decodeIOTime adds up the I/O timings, PG15 reports the temp ones beside "I/O Read Time" and PG16 splits them into
+// shared, local and temp. The split ones are also kept in Extra.
+func (n *PlanNode) decodeIOTime(raw Node, key string, total *float64) error {
+	value, err := floatProperty(n.Path, raw, key)
+	if err != nil {
+		return err
+	}
+
+	*total += value
+	if key != IO_READ_TIME && key != IO_WRITE_TIME {
+		n.Extra[key] = raw[key]
+	}
+	return nil
+}
+
+// Get returns the value of a property by its key as it would be found in the plan, nil if the node doesn't have it
+func (n *PlanNode) Get(key string) interface{} {
+	switch key {
+	case NODE_TYPE:
+		return nilIfEmpty(n.NodeType)
+	case PARENT_RELATIONSHIP:
+		return nilIfEmpty(n.ParentRelationship)
+	case SUBPLAN_NAME:
+		return nilIfEmpty(n.SubplanName)
+	case JOIN_TYPE:
+		return nilIfEmpty(n.JoinType)
+	case PARALLEL_AWARE:
+		return n.ParallelAware
+	case RELATION_NAME:
+		return nilIfEmpty(n.RelationName)
+	case SCHEMA:
+		return nilIfEmpty(n.Schema)
+	case ALIAS:
+		return nilIfEmpty(n.Alias)
+	case INDEX_NAME:
+		return nilIfEmpty(n.IndexName)
+	case CTE_NAME:
+		return nilIfEmpty(n.CTEName)
+	case FUNCTION_NAME:
+		return nilIfEmpty(n.FunctionName)
+	case FILTER:
+		return nilIfEmpty(n.Filter)
+	case JOIN_FILTER:
+		return nilIfEmpty(n.JoinFilter)
+	case INDEX_CONDITION:
+		return nilIfEmpty(n.IndexCondition)
+	case OUTPUT:
+		if n.Output == nil {
+			return nil
+		}
+		output := make([]interface{}, 0, len(n.Output))
+		for _, column := range n.Output {
+			output = append(output, column)
+		}
+		return output
+	case STARTUP_COST:
+		return n.StartupCost
+	case TOTAL_COST:
+		return n.TotalCost
+	case PLAN_ROWS:
+		return n.PlanRows
+	case PLAN_WIDTH:
+		return n.PlanWidth
+	case ACTUAL_STARTUP_TIME:
+		return nilIfAbsent(n.ActualStartupTime)
+	case ACTUAL_TOTAL_TIME:
+		return nilIfAbsent(n.ActualTotalTime)
+	case ACTUAL_ROWS:
+		return nilIfAbsent(n.ActualRows)
+	case ACTUAL_LOOPS:
+		return nilIfAbsent(n.ActualLoops)
+	case ROWS_REMOVED_BY_FILTER:
+		return nilIfAbsent(n.RowsRemovedByFilter)
+	case ROWS_REMOVED_BY_JOIN_FILTER:
+		return nilIfAbsent(n.RowsRemovedByJoinFilter)
+	case ROWS_REMOVED_BY_RECHECK:
+		return nilIfAbsent(n.RowsRemovedByRecheck)
+	case WORKERS_PLANNED:
+		return nilIfAbsent(n.WorkersPlanned)
+	case WORKERS_LAUNCHED:
+		return nilIfAbsent(n.WorkersLaunched)
+	case IO_READ_TIME:
+		return n.IOReadTime
+	case IO_WRITE_TIME:
+		return n.IOWriteTime
+	}
+
+	if field := n.Buffers.field(key); field != nil {
+		return *field
+	}
+	if field := n.WAL.field(key); field != nil {
+		return *field
+	}
+
+	return n.Extra[key]
+}
+
+func (b *NodeBuffers) field(key string) *float64 {
+	if b == nil {
+		return nil
+	}
+
+	switch key {
+	case SHARED_HIT_BLOCKS:
+		return &b.SharedHit
+	case SHARED_READ_BLOCKS:
+		return &b.SharedRead
+	case SHARED_DIRTIED_BLOCKS:
+		return &b.SharedDirtied
+	case SHARED_WRITTEN_BLOCKS:
+		return &b.SharedWritten
+	case LOCAL_HIT_BLOCKS:
+		return &b.LocalHit
+	case LOCAL_READ_BLOCKS:
+		return &b.LocalRead
+	case LOCAL_DIRTIED_BLOCKS:
+		return &b.LocalDirtied
+	case LOCAL_WRITTEN_BLOCKS:
+		return &b.LocalWritten
+	case TEMP_READ_BLOCKS:
+		return &b.TempRead
+	case TEMP_WRITTEN_BLOCKS:
+		return &b.TempWritten
+	}
+
+	return nil
+}
+
+func isBufferProperty(key string) bool {
+	for _, property := range bufferProperties {
+		if property == key {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *NodeWAL) field(key string) *float64 {
+	if w == nil {
+		return nil
+	}
+
+	switch key {
+	case WAL_RECORDS:
+		return &w.Records
+	case WAL_BYTES:
+		return &w.Bytes
+	case WAL_FPI:
+		return &w.FPI
+	}
+
+	return nil
+}
+
+func isWALProperty(key string) bool {
+	for _, property := range walProperties {
+		if property == key {
+			return true
+		}
+	}
+	return false
+}
+
+// subNodes returns the children of the node followed by the roots of the plans of its Citus tasks, the gatherers of
+// stats walk both as the tasks scan the shards of the tables
+func (n *PlanNode) subNodes() []*PlanNode {
+	if len(n.Tasks) == 0 {
+		return n.Plans
+	}
+
+	nodes := make([]*PlanNode, 0, len(n.Plans)+len(n.Tasks))
+	nodes = append(nodes, n.Plans...)
+	for _, task := range n.Tasks {
+		nodes = append(nodes, task.Plan)
+	}
+	return nodes
+}
+
+func decodePlans(path string, value interface{}) ([]*PlanNode, error) {
+	children, ok := value.([]interface{})
+	if !ok {
+		return nil, newPlanError(path, PLANS_PROP, "array of nodes", value)
+	}
+
+	plans := make([]*PlanNode, 0, len(children))
+	for i, child := range children {
+		raw, ok := child.(Node)
+		if !ok {
+			return nil, newPlanError(childPath(path, PLANS_PROP, i), "", "node", child)
+		}
+
+		node, err := newPlanNode(raw, childPath(path, PLANS_PROP, i))
+		if err != nil {
+			return nil, err
+		}
+		plans = append(plans, node)
+	}
+
+	return plans, nil
+}
+
+func decodeWorkers(path string, value interface{}) ([]Node, error) {
+	list, ok := value.([]interface{})
+	if !ok {
+		return nil, newPlanError(path, WORKERS, "array of workers", value)
+	}
+
+	workers := make([]Node, 0, len(list))
+	for i, w := range list {
+		worker, ok := w.(Node)
+		if !ok {
+			return nil, newPlanError(childPath(path, WORKERS, i), "", "worker", w)
+		}
+		workers = append(workers, worker)
+	}
+
+	return workers, nil
+}
+
+func nilIfEmpty(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	return value
+}
+
+func nilIfAbsent(value *float64) interface{} {
+	if value == nil {
+		return nil
+	}
+	return *value
+}
+
+func floatValue(value *float64) float64 {
+	if value == nil {
+		return 0.0
+	}
+	return *value
+}
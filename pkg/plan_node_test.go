@@ -0,0 +1,105 @@
+package pkg
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestNewPlanNode(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     Node
+		want    map[string]interface{}
+		wantErr *PlanError
+	}{
+		{
+			name: "typed and extra properties",
+			raw: Node{
+				NODE_TYPE:          "Index Scan",
+				INDEX_NAME:         "b_pkey",
+				TOTAL_COST:         "1.02",
+				ACTUAL_ROWS:        2.0,
+				SHARED_HIT_BLOCKS:  3.0,
+				"Scan Direction":   "Forward",
+				SORT_KEY:           []interface{}{"a"},
+				PLANS_PROP:         []interface{}{Node{NODE_TYPE: "Seq Scan"}},
+				WORKERS:            []interface{}{Node{"Worker Number": 0.0}},
+				SHARED_READ_BLOCKS: 1.0,
+			},
+			want: map[string]interface{}{
+				NODE_TYPE:          "Index Scan",
+				INDEX_NAME:         "b_pkey",
+				TOTAL_COST:         1.02,
+				ACTUAL_ROWS:        2.0,
+				ACTUAL_LOOPS:       nil,
+				SHARED_HIT_BLOCKS:  3.0,
+				SHARED_READ_BLOCKS: 1.0,
+				"Scan Direction":   "Forward",
+				SORT_KEY:           []interface{}{"a"},
+				RELATION_NAME:      nil,
+			},
+		},
+		{
+			name: "missing buffers",
+			raw: Node{
+				NODE_TYPE: "Result",
+			},
+			want: map[string]interface{}{
+				SHARED_HIT_BLOCKS: nil,
+				PLAN_ROWS:         0.0,
+			},
+		},
+		{
+			name: "wrong type",
+			raw: Node{
+				NODE_TYPE:   "Result",
+				ACTUAL_ROWS: true,
+			},
+			wantErr: &PlanError{Path: "", Key: ACTUAL_ROWS, Expected: "number", Got: "boolean true"},
+		},
+		{
+			name: "wrong child",
+			raw: Node{
+				NODE_TYPE:  "Result",
+				PLANS_PROP: []interface{}{Node{NODE_TYPE: "Seq Scan"}, "Seq Scan"},
+			},
+			wantErr: &PlanError{Path: "Plans[1]", Expected: "node", Got: `string "Seq Scan"`},
+		},
+		{
+			name: "wrong property of a nested node",
+			raw: Node{
+				NODE_TYPE: "Result",
+				PLANS_PROP: []interface{}{Node{
+					NODE_TYPE:  "Gather",
+					PLANS_PROP: []interface{}{Node{NODE_TYPE: "Seq Scan", TOTAL_COST: "abc"}},
+				}},
+			},
+			wantErr: &PlanError{Path: "Plans[0].Plans[0]", Key: TOTAL_COST, Expected: "number", Got: `string "abc"`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewPlanNode(tt.raw)
+			if tt.wantErr != nil {
+				var planErr *PlanError
+				if !errors.As(err, &planErr) {
+					t.Fatalf("NewPlanNode() error = %v, want *PlanError", err)
+				}
+				if !reflect.DeepEqual(planErr, tt.wantErr) {
+					t.Errorf("NewPlanNode() error = %+v, want %+v", planErr, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for key, want := range tt.want {
+				if value := got.Get(key); !reflect.DeepEqual(value, want) {
+					t.Errorf("NewPlanNode().Get(%v) = %v, want %v", key, value, want)
+				}
+			}
+		})
+	}
+}
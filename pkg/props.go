@@ -37,52 +37,103 @@ const (
 	BATCHES         = "Batches"
 	ARRAY_INDEX_KEY = "arrayIndex"
 
-	RELATION_NAME               = "Relation Name"
-	SCHEMA                      = "Schema"
-	ALIAS                       = "Alias"
-	GROUP_KEY                   = "Group Key"
-	SORT_KEY                    = "Sort Key"
-	SORT_METHOD                 = "Sort Method"
-	SORT_SPACE_TYPE             = "Sort Space Type"
-	SORT_SPACE_USED             = "Sort Space Used"
-	JOIN_TYPE                   = "Join Type"
-	INDEX_NAME                  = "Index Name"
-	HASH_CONDITION              = "Hash Cond"
-	PARALLEL_AWARE              = "Parallel Aware"
-	WORKERS                     = "Workers"
-	WORKERS_PLANNED             = "Workers Planned"
-	WORKERS_LAUNCHED            = "Workers Launched"
-	SHARED_HIT_BLOCKS           = "Shared Hit Blocks"
-	SHARED_READ_BLOCKS          = "Shared Read Blocks"
-	SHARED_DIRTIED_BLOCKS       = "Shared Dirtied Blocks"
-	SHARED_WRITTEN_BLOCKS       = "Shared Written Blocks"
-	TEMP_READ_BLOCKS            = "Temp Read Blocks"
-	TEMP_WRITTEN_BLOCKS         = "Temp Written Blocks"
-	LOCAL_HIT_BLOCKS            = "Local Hit Blocks"
-	LOCAL_READ_BLOCKS           = "Local Read Blocks"
-	LOCAL_DIRTIED_BLOCKS        = "Local Dirtied Blocks"
-	LOCAL_WRITTEN_BLOCKS        = "Local Written Blocks"
-	IO_READ_TIME                = "I/O Read Time"
-	IO_WRITE_TIME               = "I/O Write Time"
-	OUTPUT                      = "Output"
-	HEAP_FETCHES                = "Heap Fetches"
-	WAL_RECORDS                 = "WAL Records"
-	WAL_BYTES                   = "WAL Bytes"
-	WAL_FPI                     = "WAL FPI"
-	FULL_SORT_GROUPS            = "Full-sort Groups"
-	PRE_SORTED_GROUPS           = "Pre-sorted Groups"
-	PRESORTED_KEY               = "Presorted Key"
-	HEAP_BLOCKS                 = "Heap Blocks"
-	NODE_ID                     = "nodeId"
-	EXCLUSIVE_DURATION          = "*Duration (exclusive)"
-	EXCLUSIVE_COST              = "*Cost (exclusive)"
-	ACTUAL_ROWS_REVISED         = "*Actual Rows Revised"
-	PLAN_ROWS_REVISED           = "*Plan Rows Revised"
-	ROWS_REMOVED_BY_FILTER      = "Rows Removed by Filter"
-	ROWS_REMOVED_BY_JOIN_FILTER = "Rows Removed by Join Filter"
-	FILTER                      = "Filter"
-	JOIN_FILTER                 = "Join Filter"
-	WORKERS_PLANNED_BY_GATHER   = "*Workers Planned By Gather"
+	RELATION_NAME                  = "Relation Name"
+	SCHEMA                         = "Schema"
+	ALIAS                          = "Alias"
+	GROUP_KEY                      = "Group Key"
+	SORT_KEY                       = "Sort Key"
+	SORT_METHOD                    = "Sort Method"
+	SORT_SPACE_TYPE                = "Sort Space Type"
+	SORT_SPACE_USED                = "Sort Space Used"
+	JOIN_TYPE                      = "Join Type"
+	INDEX_NAME                     = "Index Name"
+	HASH_CONDITION                 = "Hash Cond"
+	PARALLEL_AWARE                 = "Parallel Aware"
+	WORKERS                        = "Workers"
+	WORKERS_PLANNED                = "Workers Planned"
+	WORKERS_LAUNCHED               = "Workers Launched"
+	SHARED_HIT_BLOCKS              = "Shared Hit Blocks"
+	SHARED_READ_BLOCKS             = "Shared Read Blocks"
+	SHARED_DIRTIED_BLOCKS          = "Shared Dirtied Blocks"
+	SHARED_WRITTEN_BLOCKS          = "Shared Written Blocks"
+	TEMP_READ_BLOCKS               = "Temp Read Blocks"
+	TEMP_WRITTEN_BLOCKS            = "Temp Written Blocks"
+	LOCAL_HIT_BLOCKS               = "Local Hit Blocks"
+	LOCAL_READ_BLOCKS              = "Local Read Blocks"
+	LOCAL_DIRTIED_BLOCKS           = "Local Dirtied Blocks"
+	LOCAL_WRITTEN_BLOCKS           = "Local Written Blocks"
+	IO_READ_TIME                   = "I/O Read Time"
+	IO_WRITE_TIME                  = "I/O Write Time"
+	SHARED_IO_READ_TIME            = "Shared I/O Read Time"
+	SHARED_IO_WRITE_TIME           = "Shared I/O Write Time"
+	LOCAL_IO_READ_TIME             = "Local I/O Read Time"
+	LOCAL_IO_WRITE_TIME            = "Local I/O Write Time"
+	TEMP_IO_READ_TIME              = "Temp I/O Read Time"
+	TEMP_IO_WRITE_TIME             = "Temp I/O Write Time"
+	OUTPUT                         = "Output"
+	HEAP_FETCHES                   = "Heap Fetches"
+	WAL_RECORDS                    = "WAL Records"
+	WAL_BYTES                      = "WAL Bytes"
+	WAL_FPI                        = "WAL FPI"
+	FULL_SORT_GROUPS               = "Full-sort Groups"
+	PRE_SORTED_GROUPS              = "Pre-sorted Groups"
+	PRESORTED_KEY                  = "Presorted Key"
+	CACHE_KEY                      = "Cache Key"
+	CACHE_MODE                     = "Cache Mode"
+	CACHE_HITS                     = "Cache Hits"
+	CACHE_MISSES                   = "Cache Misses"
+	CACHE_EVICTIONS                = "Cache Evictions"
+	CACHE_OVERFLOWS                = "Cache Overflows"
+	PEAK_MEMORY_USAGE              = "Peak Memory Usage"
+	DISK_USAGE                     = "Disk Usage"
+	PLANNED_PARTITIONS             = "Planned Partitions"
+	HASH_AGG_BATCHES               = "HashAgg Batches"
+	STRATEGY                       = "Strategy"
+	SINGLE_COPY                    = "Single Copy"
+	SUBPLANS_REMOVED               = "Subplans Removed"
+	DML_OPERATION                  = "Operation"
+	CONFLICT_RESOLUTION            = "Conflict Resolution"
+	CONFLICT_ARBITER_INDEXES       = "Conflict Arbiter Indexes"
+	CONFLICT_FILTER                = "Conflict Filter"
+	TUPLES_INSERTED                = "Tuples Inserted"
+	CONFLICTING_TUPLES             = "Conflicting Tuples"
+	TUPLES_UPDATED                 = "Tuples Updated"
+	TUPLES_DELETED                 = "Tuples Deleted"
+	TUPLES_SKIPPED                 = "Tuples Skipped"
+	REMOTE_SQL                     = "Remote SQL"
+	FOREIGN_RELATIONS              = "Relations"
+	ASYNC_CAPABLE                  = "Async Capable"
+	CUSTOM_PLAN_PROVIDER           = "Custom Plan Provider"
+	DISTRIBUTED_QUERY              = "Distributed Query"
+	JOB                            = "Job"
+	DISTRIBUTED_SUBPLANS           = "Subplans"
+	PLANNED_STMT                   = "PlannedStmt"
+	TASKS                          = "Tasks"
+	TASK_COUNT                     = "Task Count"
+	TASKS_SHOWN                    = "Tasks Shown"
+	REMOTE_PLAN                    = "Remote Plan"
+	WORKER_NODE                    = "Node"
+	PLANNING_TIME                  = "Planning Time"
+	EXECUTION_TIME                 = "Execution Time"
+	HYPERTABLE                     = "Hypertable"
+	CHUNKS_EXCLUDED_DURING_STARTUP = "Chunks excluded during startup"
+	CHUNKS_EXCLUDED_DURING_RUNTIME = "Chunks excluded during runtime"
+	CHUNKS_LEFT_AFTER_EXCLUSION    = "Chunks left after exclusion"
+	BULK_DECOMPRESSION             = "Bulk Decompression"
+	HEAP_BLOCKS                    = "Heap Blocks"
+	EXACT_HEAP_BLOCKS              = "Exact Heap Blocks"
+	LOSSY_HEAP_BLOCKS              = "Lossy Heap Blocks"
+	NODE_ID                        = "nodeId"
+	EXCLUSIVE_DURATION             = "*Duration (exclusive)"
+	EXCLUSIVE_COST                 = "*Cost (exclusive)"
+	ACTUAL_ROWS_REVISED            = "*Actual Rows Revised"
+	PLAN_ROWS_REVISED              = "*Plan Rows Revised"
+	ROWS_REMOVED_BY_FILTER         = "Rows Removed by Filter"
+	ROWS_REMOVED_BY_JOIN_FILTER    = "Rows Removed by Join Filter"
+	ROWS_REMOVED_BY_RECHECK        = "Rows Removed by Index Recheck"
+	FILTER                         = "Filter"
+	JOIN_FILTER                    = "Join Filter"
+	WORKERS_PLANNED_BY_GATHER      = "*Workers Planned By Gather"
 
 	CTE_SCAN = "CTE Scan"
 	CTE_NAME = "CTE Name"
@@ -108,6 +159,7 @@ const (
 	HASH                  = "Hash"
 	HASH_JOIN             = "Hash Join"
 	HASH_AGGREGATE        = "HashAggregate"
+	AGGREGATE             = "Aggregate"
 	SORT                  = "Sort"
 	FUNCTION_SCAN         = "Function Scan"
 	INCREMENTAL_SORT      = "Incremental Sort"
@@ -116,6 +168,26 @@ const (
 	NESTED_LOOP_SEMI_JOIN = "Nested Loop Semi Join"
 	MERGE_JOIN            = "Merge Join"
 	GROUP_AGGREGATE       = "GroupAggregate"
+	SUBQUERY_SCAN         = "Subquery Scan"
+	APPEND                = "Append"
+	MERGE_APPEND          = "Merge Append"
+	RECURSIVE_UNION       = "Recursive Union"
+	SET_OP                = "SetOp"
+	CUSTOM_SCAN           = "Custom Scan"
+	FOREIGN_SCAN          = "Foreign Scan"
+	PROJECT_SET           = "ProjectSet"
+	TABLE_FUNCTION_SCAN   = "Table Function Scan"
+	MEMOIZE               = "Memoize"
+	MATERIALIZE           = "Materialize"
+	RESULT                = "Result"
+	GATHER                = "Gather"
+	GATHER_MERGE          = "Gather Merge"
+	MODIFY_TABLE          = "ModifyTable"
+
+	// Custom Scan providers
+	CHUNK_APPEND            = "ChunkAppend"
+	CONSTRAINT_AWARE_APPEND = "ConstraintAwareAppend"
+	DECOMPRESS_CHUNK        = "DecompressChunk"
 
 	// Others
 
@@ -0,0 +1,78 @@
+package pkg
+
+import "sync"
+
+// OperationDescriptor teaches the summary about a node type it doesn't know, e.g. the nodes of an extension. The
+// scope fields are the property keys the scopes are read from, the functions are optional.
+type OperationDescriptor struct {
+	RelationName string
+	Index        string
+	Filter       string
+	Key          string
+	Condition    string
+	Command      string
+
+	// Properties returns the node type specific properties of a node
+	Properties func(node *PlanNode) ([]Property, error)
+
+	// Workers returns the properties of each of the workers of a node
+	Workers func(node *PlanNode) ([][]Property, error)
+
+	// Insights returns the warnings and the informational notes about a node
+	Insights func(node *PlanNode) (warnings []string, infos []string, err error)
+}
+
+func (d OperationDescriptor) operation() Operation {
+	return Operation{
+		RelationName:          d.RelationName,
+		Index:                 d.Index,
+		Filter:                d.Filter,
+		Key:                   d.Key,
+		Condition:             d.Condition,
+		Command:               d.Command,
+		getSpecificProperties: d.Properties,
+		getWorkers:            d.Workers,
+		getInsights:           d.Insights,
+	}
+}
+
+var registry = struct {
+	sync.RWMutex
+	operations  map[string]Operation
+	customScans map[string]Operation
+}{
+	operations:  map[string]Operation{},
+	customScans: map[string]Operation{},
+}
+
+// RegisterOperation describes the nodes of a type, it's consulted when the type isn't one of the built-in
+// operations. A later registration of the same type replaces the previous one.
+func RegisterOperation(nodeType string, descriptor OperationDescriptor) {
+	registry.Lock()
+	defer registry.Unlock()
+	registry.operations[nodeType] = descriptor.operation()
+}
+
+// RegisterCustomScan describes the Custom Scan nodes of a provider, e.g. ChunkAppend or Citus Adaptive. It takes
+// precedence over the descriptor registered for all the Custom Scan nodes.
+func RegisterCustomScan(provider string, descriptor OperationDescriptor) {
+	registry.Lock()
+	defer registry.Unlock()
+	registry.customScans[provider] = descriptor.operation()
+}
+
+func registeredOperation(node *PlanNode) (Operation, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	if node.NodeType == CUSTOM_SCAN {
+		if provider, ok := node.Extra[CUSTOM_PLAN_PROVIDER].(string); ok {
+			if operation, ok := registry.customScans[provider]; ok {
+				return operation, true
+			}
+		}
+	}
+
+	operation, ok := registry.operations[node.NodeType]
+	return operation, ok
+}
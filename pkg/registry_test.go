@@ -0,0 +1,72 @@
+package pkg
+
+import (
+	"testing"
+)
+
+func TestRegisterOperation(t *testing.T) {
+	RegisterCustomScan("VectorScan", OperationDescriptor{
+		RelationName: RELATION_NAME,
+		Index:        INDEX_NAME,
+		Key:          "Order By",
+		Properties: func(node *PlanNode) ([]Property, error) {
+			return []Property{{ID: "ef_search", Name: "ef_search", Type: "float", ValueFloat: 40, Kind: Quantity}}, nil
+		},
+		Insights: func(node *PlanNode) ([]string, []string, error) {
+			return nil, []string{"Approximate search"}, nil
+		},
+	})
+	RegisterOperation("Custom Scan", OperationDescriptor{RelationName: RELATION_NAME})
+	RegisterOperation(SORT, OperationDescriptor{})
+	defer func() {
+		delete(registry.customScans, "VectorScan")
+		delete(registry.operations, "Custom Scan")
+		delete(registry.operations, SORT)
+	}()
+
+	tests := []struct {
+		name           string
+		plan           string
+		wantScopes     NodeScopes
+		wantProperties int
+		wantInfos      int
+	}{
+		{
+			name:           "registered provider",
+			plan:           `[{"Plan":{"Node Type":"Custom Scan","Custom Plan Provider":"VectorScan","Relation Name":"items","Index Name":"items_embedding_idx","Order By":"(embedding <-> '[1,2]')","Total Cost":10,"Plan Rows":5}}]`,
+			wantScopes:     NodeScopes{Table: "items", Index: "items_embedding_idx", Key: "(embedding <-> '[1,2]')"},
+			wantProperties: 1,
+			wantInfos:      1,
+		},
+		{
+			name:       "other provider falls back to the node type",
+			plan:       `[{"Plan":{"Node Type":"Custom Scan","Custom Plan Provider":"Other","Relation Name":"items","Index Name":"items_embedding_idx","Total Cost":10,"Plan Rows":5}}]`,
+			wantScopes: NodeScopes{Table: "items"},
+		},
+		{
+			name:           "built-in operations come first",
+			plan:           `[{"Plan":{"Node Type":"Sort","Sort Key":["id"],"Total Cost":10,"Plan Rows":5}}]`,
+			wantScopes:     NodeScopes{Key: "[\n    \"id\"\n]"},
+			wantProperties: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			explained, err := Explain(tt.plan, ExplainOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			row := explained.Summary[0]
+			if row.Scopes != tt.wantScopes {
+				t.Errorf("scopes = %+v, want %+v", row.Scopes, tt.wantScopes)
+			}
+			if len(row.NodeTypeSpecificProperties) != tt.wantProperties {
+				t.Errorf("properties = %+v, want %v", row.NodeTypeSpecificProperties, tt.wantProperties)
+			}
+			if len(row.Infos) != tt.wantInfos {
+				t.Errorf("infos = %v, want %v", row.Infos, tt.wantInfos)
+			}
+		})
+	}
+}
@@ -1,8 +1,8 @@
 package pkg
 
 import (
-	"encoding/json"
 	"fmt"
+	"math"
 	"sort"
 )
 
@@ -10,52 +10,70 @@ type StatsGather struct {
 	Stats
 	indexesStats map[string]IndexStats
 	tablesStats  map[string]TableStats
+	partitions   map[*PlanNode]string
 	nodesStats   map[string]NodeStats
 	jit          *JIT
-	triggers     []struct {
-		Name  string  `json:"Trigger Name"`
-		Time  float64 `json:"Time"`
-		Calls string  `json:"Calls"`
-	}
+	triggers     []TriggerFromPlan
 }
 
 func NewStatsGather() *StatsGather {
 	return &StatsGather{
 		indexesStats: make(map[string]IndexStats),
 		tablesStats:  make(map[string]TableStats),
+		partitions:   make(map[*PlanNode]string),
 		nodesStats:   make(map[string]NodeStats),
 	}
 }
 
 func (s *StatsGather) GetStatsFromPlans(plans string) error {
-	var p []StatsFromPlan
-	if err := json.Unmarshal([]byte(plans), &p); err != nil {
-		return fmt.Errorf("could not unmarshal plan: %v", err)
+	p, err := getStatsFromPlans(plans)
+	if err != nil {
+		return err
 	}
 
-	if p[0].ExecutionTime != 0 {
-		s.PlanningTime = p[0].PlanningTime
-		s.ExecutionTime = p[0].ExecutionTime
+	s.GetStatsFromPlan(p[0])
+
+	return nil
+}
+
+// GetStatsFromPlan reads the stats of a single query, use it when a plan contains more than one
+func (s *StatsGather) GetStatsFromPlan(p StatsFromPlan) {
+	if p.ExecutionTime != 0 {
+		s.PlanningTime = p.PlanningTime
+		s.ExecutionTime = p.ExecutionTime
 	} else {
-		s.PlanningTime = p[0].Plan.PlanningTime
-		s.ExecutionTime = p[0].Plan.ExecutionTime
+		s.PlanningTime = p.Plan.PlanningTime
+		s.ExecutionTime = p.Plan.ExecutionTime
 	}
 
-	if p[0].JIT != nil {
-		s.jit = p[0].JIT
+	if p.JIT != nil {
+		s.jit = p.JIT
 	} else {
-		s.jit = p[0].Plan.JIT
+		s.jit = p.Plan.JIT
 	}
 
-	s.triggers = p[0].Triggers
-
-	return nil
+	s.triggers = p.Triggers
 }
 
-func (s *StatsGather) ComputeStats(node Node) Stats {
+func (s *StatsGather) ComputeStats(node *PlanNode) Stats {
 	s.calculateMaximums(node)
 	s.findOutlierNodes(node)
 
+	// The WAL of the root node includes the WAL of all its sub nodes
+	wal := NodeWAL{}
+	if node.WAL != nil {
+		wal = *node.WAL
+	}
+
+	// The IO timings of the root node include the ones of all its sub nodes, the remaining execution time
+	// is spent on CPU
+	ioTime := node.IOReadTime + node.IOWriteTime
+	cpuTime, ioPercentage := 0.0, 0.0
+	if s.ExecutionTime != 0.0 {
+		cpuTime = math.Max(s.ExecutionTime-ioTime, 0)
+		ioPercentage = getPercentage(ioTime, s.ExecutionTime)
+	}
+
 	return Stats{
 		ExecutionTime:    s.ExecutionTime,
 		PlanningTime:     s.PlanningTime,
@@ -65,10 +83,18 @@ func (s *StatsGather) ComputeStats(node Node) Stats {
 		MaxBlocksRead:    getMaxBlocksRead(node),
 		MaxBlocksWritten: getMaxBlocksWritten(node),
 		MaxBlocksHit:     getMaxBlocksHits(node),
+		WALRecords:       wal.Records,
+		WALBytes:         wal.Bytes,
+		WALFPI:           wal.FPI,
+		IOReadTime:       node.IOReadTime,
+		IOWriteTime:      node.IOWriteTime,
+		IOTime:           ioTime,
+		CPUTime:          cpuTime,
+		IOPercentage:     ioPercentage,
 	}
 }
 
-func (s *StatsGather) ComputeIndexesStats(node Node) IndexesStats {
+func (s *StatsGather) ComputeIndexesStats(node *PlanNode) IndexesStats {
 	s.computeIndexesStats(node)
 
 	// For only EXPLAIN plans 'Execution Time" is missing
@@ -78,6 +104,10 @@ func (s *StatsGather) ComputeIndexesStats(node Node) IndexesStats {
 			s.indexesStats[indexName] = index
 		}
 	}
+	for indexName, index := range s.indexesStats {
+		index.Aliases = s.completeAliasesStats(index.Aliases)
+		s.indexesStats[indexName] = index
+	}
 
 	indexesSlice := make([]IndexStats, 0)
 	for indexName, index := range s.indexesStats {
@@ -94,7 +124,11 @@ func (s *StatsGather) ComputeIndexesStats(node Node) IndexesStats {
 	}
 }
 
-func (s *StatsGather) ComputeTablesStats(node Node) TablesStats {
+// ComputeTablesStats rolls the partitions up into their parent table, the one inferred from their names, and the
+// chunks up into their hypertable, beside the stats of the partitions and the chunks themselves
+func (s *StatsGather) ComputeTablesStats(node *PlanNode) TablesStats {
+	partitionParents(node, s.partitions)
+	hypertableChunks(node, "", s.partitions)
 	s.computeTablesStats(node)
 
 	// For only EXPLAIN plans 'Execution Time" is missing
@@ -104,6 +138,10 @@ func (s *StatsGather) ComputeTablesStats(node Node) TablesStats {
 			s.tablesStats[tableName] = table
 		}
 	}
+	for tableName, table := range s.tablesStats {
+		table.Aliases = s.completeAliasesStats(table.Aliases)
+		s.tablesStats[tableName] = table
+	}
 
 	tablesSlice := make([]TableStats, 0)
 	for tableName, table := range s.tablesStats {
@@ -116,11 +154,45 @@ func (s *StatsGather) ComputeTablesStats(node Node) TablesStats {
 	})
 
 	return TablesStats{
-		Tables: tablesSlice,
+		Tables:      tablesSlice,
+		Partitioned: partitionedTables(tablesSlice),
 	}
 }
 
-func (s *StatsGather) ComputeNodesStats(node Node) NodesStats {
+func partitionedTables(tables []TableStats) []PartitionedTableStats {
+	parents := map[string]*PartitionedTableStats{}
+	for _, table := range tables {
+		if table.Parent == "" {
+			continue
+		}
+
+		parent, ok := parents[table.Parent]
+		if !ok {
+			parent = &PartitionedTableStats{Name: table.Parent, Partitions: make([]string, 0)}
+			parents[table.Parent] = parent
+		}
+		parent.Partitions = append(parent.Partitions, table.Name)
+		parent.TotalTime += table.TotalTime
+		parent.IOTime += table.IOTime
+		parent.Percentage += table.Percentage
+	}
+
+	partitioned := make([]PartitionedTableStats, 0, len(parents))
+	for _, parent := range parents {
+		sort.Strings(parent.Partitions)
+		partitioned = append(partitioned, *parent)
+	}
+	sort.Slice(partitioned, func(i, j int) bool {
+		if partitioned[i].TotalTime != partitioned[j].TotalTime {
+			return partitioned[i].TotalTime > partitioned[j].TotalTime
+		}
+		return partitioned[i].Name < partitioned[j].Name
+	})
+
+	return partitioned
+}
+
+func (s *StatsGather) ComputeNodesStats(node *PlanNode) NodesStats {
 	s.computeNodesStats(node)
 
 	// For only EXPLAIN plans 'Execution Time" is missing
@@ -150,17 +222,22 @@ func (s *StatsGather) ComputeJITStats() *JIT {
 	return s.jit
 }
 
-func (s *StatsGather) ComputeTriggersStats() *Triggers {
+func (s *StatsGather) ComputeTriggersStats() (*Triggers, error) {
 	if s.triggers != nil && len(s.triggers) > 0 {
 		maxTime := 0.0
 		triggers := make([]Trigger, 0)
-		for _, trigger := range s.triggers {
-			calls := ConvertStringToFloat64(trigger.Calls)
+		for i, trigger := range s.triggers {
+			calls, err := ConvertToFloat64(trigger.Calls)
+			if err != nil {
+				return nil, newPlanError(fmt.Sprintf("Triggers[%d]", i), "Calls", "number", trigger.Calls)
+			}
 			tr := Trigger{
-				Name:    trigger.Name,
-				Time:    trigger.Time,
-				Calls:   calls,
-				AvgTime: trigger.Time / calls,
+				Name:     trigger.Name,
+				Relation: trigger.Relation,
+				Schema:   trigger.Schema,
+				Time:     trigger.Time,
+				Calls:    calls,
+				AvgTime:  trigger.Time / calls,
 			}
 			triggers = append(triggers, tr)
 
@@ -172,140 +249,238 @@ func (s *StatsGather) ComputeTriggersStats() *Triggers {
 		return &Triggers{
 			MaxTime: maxTime,
 			Items:   triggers,
+		}, nil
+	}
+
+	return nil, nil
+}
+
+// ComputeSpillStats returns nil when none of the hash aggregates reports its memory usage
+func (s *StatsGather) ComputeSpillStats(node *PlanNode) (*SpillStats, error) {
+	aggregates := make([]HashAggregateStats, 0)
+	if err := s.computeSpillStats(node, &aggregates); err != nil {
+		return nil, err
+	}
+
+	if len(aggregates) == 0 {
+		return nil, nil
+	}
+
+	stats := &SpillStats{Aggregates: aggregates}
+	for _, aggregate := range aggregates {
+		if aggregate.Spilled {
+			stats.Spilled++
+			stats.DiskUsage += aggregate.DiskUsage
+		}
+	}
+
+	return stats, nil
+}
+
+func (s *StatsGather) computeSpillStats(node *PlanNode, aggregates *[]HashAggregateStats) error {
+	if isHashAggregate(node) {
+		aggregate, err := newHashAggregateStats(node)
+		if err != nil {
+			return err
+		}
+		if aggregate != nil {
+			*aggregates = append(*aggregates, *aggregate)
+		}
+	}
+
+	for _, subNode := range node.subNodes() {
+		if err := s.computeSpillStats(subNode, aggregates); err != nil {
+			return err
 		}
 	}
 
 	return nil
 }
 
-func (s *StatsGather) computeIndexesStats(node Node) {
-	if node[INDEX_NAME] != nil {
-		indexName := node[INDEX_NAME].(string)
+// newHashAggregateStats returns nil when the node doesn't report its memory usage, i.e. before PG13 or without ANALYZE
+func newHashAggregateStats(node *PlanNode) (*HashAggregateStats, error) {
+	if node.Extra[PEAK_MEMORY_USAGE] == nil && node.Extra[HASH_AGG_BATCHES] == nil {
+		return nil, nil
+	}
 
-		indexes := s.indexesStats[indexName]
-		indexNode := IndexNode{
-			Id:            node[NODE_ID].(string),
-			Type:          node[NODE_TYPE].(string),
-			ExclusiveTime: ConvertToFloat64(node[EXCLUSIVE_DURATION]),
+	key, err := scopeProperty(node.Path, GROUP_KEY, node.Get(GROUP_KEY))
+	if err != nil {
+		return nil, err
+	}
+
+	aggregate := &HashAggregateStats{
+		Id:        node.NodeId,
+		Operation: node.NodeType,
+		Key:       key,
+	}
+	if node.Extra[PLANNED_PARTITIONS] != nil {
+		if aggregate.PlannedPartitions, err = floatProperty(node.Path, node.Extra, PLANNED_PARTITIONS); err != nil {
+			return nil, err
+		}
+	}
+
+	processes := append([]Node{node.Extra}, node.Workers...)
+	for i, values := range processes {
+		path := node.Path
+		if i > 0 {
+			path = childPath(node.Path, WORKERS, i-1)
+		}
+
+		usage := map[string]float64{}
+		for _, key := range []string{HASH_AGG_BATCHES, PEAK_MEMORY_USAGE, DISK_USAGE} {
+			if values[key] == nil {
+				continue
+			}
+			if usage[key], err = floatProperty(path, values, key); err != nil {
+				return nil, err
+			}
+		}
+
+		aggregate.Batches = math.Max(aggregate.Batches, usage[HASH_AGG_BATCHES])
+		aggregate.PeakMemoryUsage = math.Max(aggregate.PeakMemoryUsage, usage[PEAK_MEMORY_USAGE])
+		aggregate.DiskUsage += usage[DISK_USAGE]
+
+		// A single batch is kept in memory
+		if usage[HASH_AGG_BATCHES] > 1 || usage[DISK_USAGE] > 0 {
+			aggregate.Spilled = true
+			if i > 0 {
+				aggregate.SpilledWorkers++
+			}
 		}
+	}
+
+	return aggregate, nil
+}
 
-		if node[INDEX_CONDITION] != nil {
-			indexNode.Condition = node[INDEX_CONDITION].(string)
+func (s *StatsGather) computeIndexesStats(node *PlanNode) {
+	if node.IndexName != "" {
+		indexName := qualifiedName(node.Schema, node.IndexName)
+		indexes := s.indexesStats[indexName]
+		indexNode := IndexNode{
+			Id:            node.NodeId,
+			Type:          node.NodeType,
+			ExclusiveTime: node.ExclusiveDuration,
+			Condition:     node.IndexCondition,
+			Alias:         node.Alias,
 		}
 
 		indexes.Nodes = append(indexes.Nodes, indexNode)
-		indexes.TotalTime += ConvertToFloat64(node[EXCLUSIVE_DURATION])
+		indexes.TotalTime += node.ExclusiveDuration
+		indexes.Schema = node.Schema
+		indexes.Aliases = addAliasStats(indexes.Aliases, node.Alias, node.ExclusiveDuration)
 
 		s.indexesStats[indexName] = indexes
 	}
 
-	if node[PLANS_PROP] != nil {
-		for _, subNode := range node[PLANS_PROP].([]interface{}) {
-			s.computeIndexesStats(subNode.(Node))
-		}
+	for _, subNode := range node.subNodes() {
+		s.computeIndexesStats(subNode)
 	}
 }
 
-func (s *StatsGather) computeTablesStats(node Node) {
-	if node[RELATION_NAME] != nil {
-		tableName := node[RELATION_NAME].(string)
-
-		tables := s.tablesStats[tableName]
+func (s *StatsGather) computeTablesStats(node *PlanNode) {
+	if node.RelationName != "" {
+		tableName := qualifiedName(node.Schema, node.RelationName)
 		tableNode := TableNode{
-			Id:            node[NODE_ID].(string),
-			Type:          node[NODE_TYPE].(string),
-			ExclusiveTime: ConvertToFloat64(node[EXCLUSIVE_DURATION]),
+			Id:            node.NodeId,
+			Type:          node.NodeType,
+			ExclusiveTime: node.ExclusiveDuration,
+			IOTime:        getExclusiveIOTime(node),
+			Alias:         node.Alias,
 		}
+		tables := s.tablesStats[tableName]
 
 		tables.Nodes = append(tables.Nodes, tableNode)
-		tables.TotalTime += ConvertToFloat64(node[EXCLUSIVE_DURATION])
+		tables.TotalTime += node.ExclusiveDuration
+		tables.IOTime += tableNode.IOTime
+		tables.Schema = node.Schema
+		if parent, ok := s.partitions[node]; ok {
+			tables.Parent = parent
+		}
+		tables.Aliases = addAliasStats(tables.Aliases, node.Alias, node.ExclusiveDuration)
 
 		s.tablesStats[tableName] = tables
 	}
 
-	if node[PLANS_PROP] != nil {
-		for _, subNode := range node[PLANS_PROP].([]interface{}) {
-			s.computeTablesStats(subNode.(Node))
-		}
+	for _, subNode := range node.subNodes() {
+		s.computeTablesStats(subNode)
 	}
 }
 
-func (s *StatsGather) computeNodesStats(node Node) {
-	if node[NODE_TYPE] != nil {
-		nodeType := node[NODE_TYPE].(string)
-
-		nodeStats := s.nodesStats[nodeType]
-		n := NodeNode{
-			Id:            node[NODE_ID].(string),
-			Type:          node[NODE_TYPE].(string),
-			ExclusiveTime: ConvertToFloat64(node[EXCLUSIVE_DURATION]),
+func addAliasStats(aliases []AliasStats, alias string, duration float64) []AliasStats {
+	for i := range aliases {
+		if aliases[i].Alias == alias {
+			aliases[i].Nodes++
+			aliases[i].TotalTime += duration
+			return aliases
 		}
+	}
 
-		nodeStats.Nodes = append(nodeStats.Nodes, n)
-		nodeStats.TotalTime += ConvertToFloat64(node[EXCLUSIVE_DURATION])
+	return append(aliases, AliasStats{Alias: alias, Nodes: 1, TotalTime: duration})
+}
 
-		s.nodesStats[nodeType] = nodeStats
+// completeAliasesStats computes the percentages and sorts the aliases, the slowest first
+func (s *StatsGather) completeAliasesStats(aliases []AliasStats) []AliasStats {
+	// For only EXPLAIN plans 'Execution Time" is missing
+	if s.ExecutionTime != 0.0 {
+		for i := range aliases {
+			aliases[i].Percentage = (aliases[i].TotalTime / s.ExecutionTime) * 100
+		}
 	}
 
-	if node[PLANS_PROP] != nil {
-		for _, subNode := range node[PLANS_PROP].([]interface{}) {
-			s.computeNodesStats(subNode.(Node))
+	sort.SliceStable(aliases, func(i, j int) bool {
+		if aliases[i].TotalTime != aliases[j].TotalTime {
+			return aliases[i].TotalTime > aliases[j].TotalTime
 		}
-	}
+		return aliases[i].Alias < aliases[j].Alias
+	})
+
+	return aliases
 }
 
-func (s *StatsGather) findOutlierNodes(node Node) {
-	node[SLOWEST_NODE_PROP] = false
-	node[LARGEST_NODE_PROP] = false
-	node[COSTLIEST_NODE_PROP] = false
+func (s *StatsGather) computeNodesStats(node *PlanNode) {
+	if node.NodeType != "" {
+		nodeStats := s.nodesStats[node.NodeType]
+		n := NodeNode{
+			Id:            node.NodeId,
+			Type:          node.NodeType,
+			ExclusiveTime: node.ExclusiveDuration,
+		}
 
-	if node[ACTUAL_COST_PROP] == s.MaxCost {
-		node[COSTLIEST_NODE_PROP] = true
-	}
-	if node[ACTUAL_ROWS] == s.MaxRows {
-		node[LARGEST_NODE_PROP] = true
-	}
-	if node[ACTUAL_DURATION] == s.MaxDuration {
-		node[SLOWEST_NODE_PROP] = true
+		nodeStats.Nodes = append(nodeStats.Nodes, n)
+		nodeStats.TotalTime += node.ExclusiveDuration
+
+		s.nodesStats[node.NodeType] = nodeStats
 	}
 
-	if node[PLANS_PROP] != nil {
-		for _, subNode := range node[PLANS_PROP].([]interface{}) {
-			s.findOutlierNodes(subNode.(Node))
-		}
+	for _, subNode := range node.subNodes() {
+		s.computeNodesStats(subNode)
 	}
 }
 
-func (s *StatsGather) calculateMaximums(node Node) {
-	for name, value := range node {
-		s.getMaximum(name, value)
-		if name == PLANS_PROP {
-			for _, subNode := range value.([]interface{}) {
-				sn := subNode.(Node)
-				s.calculateMaximums(sn)
-			}
-		}
+func (s *StatsGather) findOutlierNodes(node *PlanNode) {
+	node.IsCostliest = node.TotalCost == s.MaxCost
+	node.IsLargest = node.ActualRows != nil && *node.ActualRows == s.MaxRows
+	node.IsSlowest = node.ActualTotalTime != nil && node.InclusiveDuration == s.MaxDuration
+
+	for _, subNode := range node.Plans {
+		s.findOutlierNodes(subNode)
 	}
 }
 
-func (s *StatsGather) getMaximum(key string, value interface{}) {
-	var valueFloat float64
-	switch value.(type) {
-	case float64:
-		valueFloat = value.(float64)
-	default:
-		return
+func (s *StatsGather) calculateMaximums(node *PlanNode) {
+	if s.MaxRows < node.ActualRowsRevised {
+		s.MaxRows = node.ActualRowsRevised
 	}
 
-	if key == ACTUAL_ROWS+REVISED && s.MaxRows < valueFloat {
-		s.MaxRows = valueFloat
+	if s.MaxCost < node.TotalCost {
+		s.MaxCost = node.TotalCost
 	}
 
-	if key == TOTAL_COST && s.MaxCost < valueFloat {
-		s.MaxCost = valueFloat
+	if s.MaxDuration < node.ExclusiveDuration {
+		s.MaxDuration = node.ExclusiveDuration
 	}
 
-	if key == EXCLUSIVE_DURATION && s.MaxDuration < valueFloat {
-		s.MaxDuration = valueFloat
+	for _, subNode := range node.Plans {
+		s.calculateMaximums(subNode)
 	}
 }
@@ -0,0 +1,115 @@
+package pkg
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStatsGather_ComputeTablesStats(t *testing.T) {
+	tests := []struct {
+		name        string
+		plan        string
+		wantTables  map[string][]AliasStats
+		wantIndexes map[string][]AliasStats
+	}{
+		{
+			name: "same relation in two schemas",
+			plan: `[{"Plan":{"Node Type":"Append","Total Cost":20,"Plan Rows":20,"Actual Startup Time":0,"Actual Total Time":5,"Actual Rows":20,"Actual Loops":1,"Plans":[` +
+				`{"Node Type":"Seq Scan","Parent Relationship":"Member","Relation Name":"orders","Schema":"public","Alias":"orders","Total Cost":10,"Plan Rows":10,"Actual Startup Time":0,"Actual Total Time":2,"Actual Rows":10,"Actual Loops":1},` +
+				`{"Node Type":"Seq Scan","Parent Relationship":"Member","Relation Name":"orders","Schema":"archive","Alias":"orders_1","Total Cost":10,"Plan Rows":10,"Actual Startup Time":0,"Actual Total Time":1,"Actual Rows":10,"Actual Loops":1}]}}]`,
+			wantTables: map[string][]AliasStats{
+				"public.orders":  {{Alias: "orders", Nodes: 1, TotalTime: 2}},
+				"archive.orders": {{Alias: "orders_1", Nodes: 1, TotalTime: 1}},
+			},
+			wantIndexes: map[string][]AliasStats{},
+		},
+		{
+			name: "self join",
+			plan: `[{"Plan":{"Node Type":"Nested Loop","Join Type":"Inner","Total Cost":20,"Plan Rows":10,"Actual Startup Time":0,"Actual Total Time":5,"Actual Rows":10,"Actual Loops":1,"Plans":[` +
+				`{"Node Type":"Seq Scan","Parent Relationship":"Outer","Relation Name":"employees","Alias":"e","Total Cost":10,"Plan Rows":10,"Actual Startup Time":0,"Actual Total Time":1,"Actual Rows":10,"Actual Loops":1},` +
+				`{"Node Type":"Index Scan","Parent Relationship":"Inner","Index Name":"employees_pkey","Relation Name":"employees","Alias":"m","Total Cost":1,"Plan Rows":1,"Actual Startup Time":0,"Actual Total Time":0.3,"Actual Rows":1,"Actual Loops":10}]}}]`,
+			wantTables: map[string][]AliasStats{
+				"employees": {{Alias: "m", Nodes: 1, TotalTime: 3}, {Alias: "e", Nodes: 1, TotalTime: 1}},
+			},
+			wantIndexes: map[string][]AliasStats{
+				"employees_pkey": {{Alias: "m", Nodes: 1, TotalTime: 3}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := GetRootNodeFromPlans(tt.plan)
+			if err != nil {
+				t.Fatal(err)
+			}
+			NewPlanEnricher().AnalyzePlan(node)
+
+			tables := map[string][]AliasStats{}
+			for _, table := range NewStatsGather().ComputeTablesStats(node).Tables {
+				tables[table.Name] = table.Aliases
+			}
+			if !reflect.DeepEqual(tables, tt.wantTables) {
+				t.Errorf("ComputeTablesStats() = %+v, want %+v", tables, tt.wantTables)
+			}
+
+			indexes := map[string][]AliasStats{}
+			for _, index := range NewStatsGather().ComputeIndexesStats(node).Indexes {
+				indexes[index.Name] = index.Aliases
+			}
+			if !reflect.DeepEqual(indexes, tt.wantIndexes) {
+				t.Errorf("ComputeIndexesStats() = %+v, want %+v", indexes, tt.wantIndexes)
+			}
+		})
+	}
+}
+
+func TestStatsGather_ComputeSpillStats(t *testing.T) {
+	tests := []struct {
+		name string
+		plan string
+		want *SpillStats
+	}{
+		{
+			name: "without hash aggregate",
+			plan: `[{"Plan":{"Node Type":"Seq Scan","Relation Name":"orders","Total Cost":10,"Plan Rows":10}}]`,
+			want: nil,
+		},
+		{
+			name: "in memory",
+			plan: `[{"Plan":{"Node Type":"Aggregate","Strategy":"Hashed","Partial Mode":"Simple","Total Cost":10,"Plan Rows":10,"Group Key":["o.customer_id"],"Planned Partitions":0,"HashAgg Batches":1,"Peak Memory Usage":24,"Disk Usage":0}}]`,
+			want: &SpillStats{
+				Aggregates: []HashAggregateStats{
+					{Operation: "Aggregate", Key: "[\n    \"o.customer_id\"\n]", Batches: 1, PeakMemoryUsage: 24},
+				},
+			},
+		},
+		{
+			name: "spilled by a worker",
+			plan: `[{"Plan":{"Node Type":"Aggregate","Strategy":"Hashed","Partial Mode":"Partial","Total Cost":10,"Plan Rows":10,"Group Key":["o.id"],"Planned Partitions":4,"HashAgg Batches":1,"Peak Memory Usage":4000,"Disk Usage":0,` +
+				`"Workers":[{"Worker Number":0,"HashAgg Batches":5,"Peak Memory Usage":4100,"Disk Usage":12000},{"Worker Number":1,"HashAgg Batches":1,"Peak Memory Usage":3000,"Disk Usage":0}]}}]`,
+			want: &SpillStats{
+				Aggregates: []HashAggregateStats{
+					{Operation: "Aggregate", Key: "[\n    \"o.id\"\n]", PlannedPartitions: 4, Batches: 5, PeakMemoryUsage: 4100, DiskUsage: 12000, SpilledWorkers: 1, Spilled: true},
+				},
+				Spilled:   1,
+				DiskUsage: 12000,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := GetRootNodeFromPlans(tt.plan)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := NewStatsGather().ComputeSpillStats(node)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ComputeSpillStats() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
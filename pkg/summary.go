@@ -2,6 +2,7 @@ package pkg
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 )
 
@@ -13,184 +14,266 @@ type cte struct {
 type Summary struct {
 	planTable []PlanRow
 	ctes      map[string]cte
+	shortIds  map[*PlanNode]float64
 }
 
 func NewSummary() *Summary {
 	return &Summary{
 		planTable: make([]PlanRow, 0),
 		ctes:      map[string]cte{},
+		shortIds:  map[*PlanNode]float64{},
 	}
 }
 
-func (s *Summary) Do(node Node, stats Stats) []PlanRow {
-	s.recurseNode(node, stats, 0, "")
-	s.recurseCTEsNodes(node[CTES].(map[string]Node), stats)
-	return s.planTable
+func (s *Summary) Do(node *PlanNode, stats Stats) ([]PlanRow, error) {
+	s.numberNodes(node)
+	if err := s.recurseNode(node, stats, 0, ""); err != nil {
+		return nil, err
+	}
+	if err := s.recurseCTEsNodes(node.CTEs, stats); err != nil {
+		return nil, err
+	}
+	return s.planTable, nil
 }
 
-func (s *Summary) recurseNode(node Node, stats Stats, level int, parentId string) {
-	id := node[NODE_ID].(string)
+func (s *Summary) recurseNode(node *PlanNode, stats Stats, level int, parentId string) error {
+	id := node.NodeId
+
+	scopes, err := s.scopes(node)
+	if err != nil {
+		return err
+	}
 
 	row := PlanRow{
 		NodeId:       id,
-		NodeShortId:  0,
+		NodeShortId:  s.shortIds[node],
 		NodeParentId: parentId,
 		Level:        level,
 		Operation:    s.getFullOperationName(node),
-		Scopes:       s.scopes(node),
-		Loops:        ConvertToFloat64(node[ACTUAL_LOOPS]),
-		Inclusive:    ConvertToFloat64(node[ACTUAL_TOTAL_TIME]),
-		Exclusive:    ConvertToFloat64(node[EXCLUSIVE_DURATION]),
+		Scopes:       scopes,
+		Loops:        floatValue(node.ActualLoops),
+		Inclusive:    node.InclusiveDuration,
+		Exclusive:    node.ExclusiveDuration,
 		Timings: Timings{
-			Inclusive:     ConvertToFloat64(node[ACTUAL_TOTAL_TIME]),
-			Exclusive:     ConvertToFloat64(node[EXCLUSIVE_DURATION]),
-			ExecutionTime: stats.ExecutionTime,
+			Inclusive:         node.InclusiveDuration,
+			Exclusive:         node.ExclusiveDuration,
+			Startup:           node.StartupDuration,
+			ActualTotalTime:   floatValue(node.ActualTotalTime),
+			ActualStartupTime: floatValue(node.ActualStartupTime),
+			ExecutionTime:     stats.ExecutionTime,
 		},
 		Rows: Rows{
-			Total:               node[ACTUAL_ROWS+REVISED].(float64),
-			TotalAvg:            ConvertToFloat64(node[ACTUAL_ROWS]),
-			PlannedRows:         node[PLAN_ROWS].(float64),
+			Total:               node.ActualRowsRevised,
+			TotalAvg:            floatValue(node.ActualRows),
+			PlannedRows:         node.PlanRows,
 			Removed:             getRowsRemovedByFilter(node),
-			EstimationFactor:    node[PLANNER_ESTIMATE_FACTOR].(float64),
-			EstimationDirection: node[PLANNER_ESTIMATE_DIRECTION].(string),
+			RemovedByRecheck:    node.RowsRemovedByRecheckRevised,
+			EstimationFactor:    node.PlannerEstimateFactor,
+			EstimationDirection: node.PlannerEstimateDirection,
 		},
 		ExecutionTime: stats.ExecutionTime,
 		Costs: Costs{
-			StartupCost: node[STARTUP_COST].(float64),
-			TotalCost:   node[EXCLUSIVE+TOTAL_COST].(float64),
-			PlanWidth:   node[PLAN_WIDTH].(float64),
+			StartupCost: node.StartupCost,
+			TotalCost:   node.ExclusiveTotalCost,
+			PlanWidth:   node.PlanWidth,
 		},
 		Workers:                    Workers{},
-		DoesContainBuffers:         node[DOES_CONTAIN_BUFFERS].(bool),
+		DoesContainBuffers:         node.DoesContainBuffers,
+		DoesContainWAL:             node.WAL != nil,
+		Output:                     node.Output,
 		NodeTypeSpecificProperties: make([]Property, 0),
 	}
 
-	operation, ok := operationsMap[node[NODE_TYPE].(string)]
-	if !ok {
-		operation = operationsMap["Default"]
-	}
+	operation := getOperation(node)
 	if operation.getSpecificProperties != nil {
-		row.NodeTypeSpecificProperties = operation.getSpecificProperties(node)
+		if row.NodeTypeSpecificProperties, err = operation.getSpecificProperties(node); err != nil {
+			return err
+		}
 	}
 	if operation.getWorkers != nil {
-		row.Workers.List = operation.getWorkers(node)
+		if row.Workers.List, err = operation.getWorkers(node); err != nil {
+			return err
+		}
+	}
+	if operation.getInsights != nil {
+		if row.Warnings, row.Infos, err = operation.getInsights(node); err != nil {
+			return err
+		}
 	}
 
-	if node[WORKERS_PLANNED_BY_GATHER] != nil {
-		row.Workers.Planned = node[WORKERS_PLANNED_BY_GATHER].(float64)
-		row.Workers.Launched = ConvertToFloat64(node[WORKERS_LAUNCHED])
+	if node.WorkersPlannedByGather != nil {
+		row.Workers.Planned = *node.WorkersPlannedByGather
+		row.Workers.Launched = floatValue(node.WorkersLaunchedByGather)
 	}
 
-	if node[DOES_CONTAIN_BUFFERS].(bool) {
+	if node.DoesContainBuffers {
+		buffers, exclusive := node.Buffers, node.ExclusiveBuffers
+
 		row.Buffers = Buffers{}
 		row.Buffers.EffectiveBlocksRead = getEffectiveBlocksRead(node)
 		row.Buffers.EffectiveBlocksWritten = getEffectiveBlocksWritten(node)
 		row.Buffers.EffectiveBlocksHits = getEffectiveBlocksHits(node)
-		row.Buffers.Reads = node[SHARED_READ_BLOCKS].(float64)
-		row.Buffers.Written = node[SHARED_WRITTEN_BLOCKS].(float64)
-		row.Buffers.Hits = node[SHARED_HIT_BLOCKS].(float64)
-		row.Buffers.Dirtied = node[SHARED_DIRTIED_BLOCKS].(float64)
-
-		if node[EXCLUSIVE+SHARED_READ_BLOCKS] != nil {
-			row.Buffers.ExclusiveReads = node[EXCLUSIVE+SHARED_READ_BLOCKS].(float64)
-			row.Buffers.ExclusiveWritten = node[EXCLUSIVE+SHARED_WRITTEN_BLOCKS].(float64)
-			row.Buffers.ExclusiveHits = node[EXCLUSIVE+SHARED_HIT_BLOCKS].(float64)
-			row.Buffers.ExclusiveDirtied = node[EXCLUSIVE+SHARED_DIRTIED_BLOCKS].(float64)
-		}
+		row.Buffers.Reads = buffers.SharedRead
+		row.Buffers.Written = buffers.SharedWritten
+		row.Buffers.Hits = buffers.SharedHit
+		row.Buffers.Dirtied = buffers.SharedDirtied
+		row.Buffers.ExclusiveReads = exclusive.SharedRead
+		row.Buffers.ExclusiveWritten = exclusive.SharedWritten
+		row.Buffers.ExclusiveHits = exclusive.SharedHit
+		row.Buffers.ExclusiveDirtied = exclusive.SharedDirtied
 
-		row.Buffers.TempReads = node[TEMP_READ_BLOCKS].(float64)
-		row.Buffers.TempWritten = node[TEMP_WRITTEN_BLOCKS].(float64)
+		row.Buffers.TempReads = buffers.TempRead
+		row.Buffers.TempWritten = buffers.TempWritten
+		row.Buffers.ExclusiveTempReads = exclusive.TempRead
+		row.Buffers.ExclusiveTempWritten = exclusive.TempWritten
 
-		if node[EXCLUSIVE+TEMP_READ_BLOCKS] != nil {
-			row.Buffers.ExclusiveTempReads = node[EXCLUSIVE+TEMP_READ_BLOCKS].(float64)
-			row.Buffers.ExclusiveTempWritten = node[EXCLUSIVE+TEMP_WRITTEN_BLOCKS].(float64)
-		}
+		row.Buffers.LocalReads = buffers.LocalRead
+		row.Buffers.LocalWritten = buffers.LocalWritten
+		row.Buffers.LocalHits = buffers.LocalHit
+		row.Buffers.LocalDirtied = buffers.LocalDirtied
+		row.Buffers.ExclusiveLocalReads = exclusive.LocalRead
+		row.Buffers.ExclusiveLocalWritten = exclusive.LocalWritten
+		row.Buffers.ExclusiveLocalHits = exclusive.LocalHit
+		row.Buffers.ExclusiveLocalDirtied = exclusive.LocalDirtied
+	}
 
-		row.Buffers.LocalReads = node[LOCAL_READ_BLOCKS].(float64)
-		row.Buffers.LocalWritten = node[LOCAL_WRITTEN_BLOCKS].(float64)
-		row.Buffers.LocalHits = node[LOCAL_HIT_BLOCKS].(float64)
-		row.Buffers.LocalDirtied = node[LOCAL_DIRTIED_BLOCKS].(float64)
+	row.IO = IO{
+		ReadTime:           node.IOReadTime,
+		WriteTime:          node.IOWriteTime,
+		ExclusiveReadTime:  node.ExclusiveIOReadTime,
+		ExclusiveWriteTime: node.ExclusiveIOWriteTime,
+		Percentage:         getPercentage(getExclusiveIOTime(node), node.ExclusiveDuration),
+	}
 
-		if node[EXCLUSIVE+LOCAL_READ_BLOCKS] != nil {
-			row.Buffers.ExclusiveLocalReads = node[EXCLUSIVE+LOCAL_READ_BLOCKS].(float64)
-			row.Buffers.ExclusiveLocalWritten = node[EXCLUSIVE+LOCAL_WRITTEN_BLOCKS].(float64)
-			row.Buffers.ExclusiveLocalHits = node[EXCLUSIVE+LOCAL_HIT_BLOCKS].(float64)
-			row.Buffers.ExclusiveLocalDirtied = node[EXCLUSIVE+LOCAL_DIRTIED_BLOCKS].(float64)
+	if node.WAL != nil {
+		row.WAL = WAL{
+			Records:          node.WAL.Records,
+			Bytes:            node.WAL.Bytes,
+			FPI:              node.WAL.FPI,
+			ExclusiveRecords: node.ExclusiveWAL.Records,
+			ExclusiveBytes:   node.ExclusiveWAL.Bytes,
+			ExclusiveFPI:     node.ExclusiveWAL.FPI,
 		}
 	}
 
-	if node[CTE_SUBPLAN_OF] != nil {
-		row.CteSubPlanOf = node[CTE_SUBPLAN_OF].(string)
-		row.ParentPlanId = s.ctes[node[CTE_SUBPLAN_OF].(string)].id
+	if node.CTESubplanOf != "" {
+		row.CteSubPlanOf = node.CTESubplanOf
+		row.ParentPlanId = s.ctes[node.CTESubplanOf].id
 	}
-	if isSubPlan(node) {
-		row.SubPlanOf = node[SUBPLAN_NAME].(string)
+	if isSubPlan(node) || isDistributedSubplan(node) {
+		row.SubPlanOf = node.SubplanName
 	}
+	row.Task = node.Task
 
-	row.NodeFingerprint = s.computeNodeFingerprint(row)
+	row.NodeFingerprint = node.Fingerprint
 
 	s.planTable = append(s.planTable, row)
 
 	// If the node is a CTE assign it to the CTEs map, the map will later be used to get the parentId in case the node
 	// is part of a CTE
-	if node[NODE_TYPE].(string) == CTE_SCAN {
-		s.ctes[node[CTE_NAME].(string)] = cte{
+	if node.NodeType == CTE_SCAN {
+		s.ctes[node.CTEName] = cte{
 			id:    id,
 			level: level,
 		}
 	}
 
-	if node[PLANS_PROP] != nil {
-		subNodes := node[PLANS_PROP].([]interface{})
-		for _, subNode := range subNodes {
-			// CTE will be recurse in a second moment in the recurseCTEsNodes method
-			if subNode.(Node)[IS_CTE_ROOT] != "true" {
-				s.recurseNode(subNode.(Node), stats, level+1, id)
+	for _, subNode := range node.Plans {
+		// CTE will be recurse in a second moment in the recurseCTEsNodes method
+		if !subNode.IsCTERoot {
+			if err := s.recurseNode(subNode, stats, level+1, id); err != nil {
+				return err
 			}
 		}
 	}
+
+	// The plans of the tasks of a distributed query are shown below it, labelled with the worker node
+	for _, task := range node.Tasks {
+		root := len(s.planTable)
+		if err := s.recurseNode(task.Plan, stats, level+1, id); err != nil {
+			return err
+		}
+		s.planTable[root].SubPlanOf = describeTask(&task.TaskInfo)
+
+		if err := s.recurseCTEsNodes(task.Plan.CTEs, stats); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
-func (s *Summary) getFullOperationName(node Node) string {
+// numberNodes gives the short ids in the order the nodes appear in the plan, the CTEs where their InitPlan is
+// rather than after the main tree as they are summarised
+func (s *Summary) numberNodes(node *PlanNode) {
+	s.shortIds[node] = float64(len(s.shortIds) + 1)
+
+	for _, subNode := range node.Plans {
+		s.numberNodes(subNode)
+	}
+	for _, task := range node.Tasks {
+		s.numberNodes(task.Plan)
+	}
+}
+
+func (s *Summary) getFullOperationName(node *PlanNode) string {
 	builder := strings.Builder{}
-	if node[PARALLEL_AWARE] != nil {
-		if node[PARALLEL_AWARE].(bool) {
-			builder.WriteString("Parallel ")
-		}
+	if node.ParallelAware {
+		builder.WriteString("Parallel ")
 	}
 
-	if node[JOIN_TYPE] != nil {
-		builder.WriteString(fmt.Sprintf("%v ", node[JOIN_TYPE].(string)))
+	if node.JoinType != "" {
+		builder.WriteString(fmt.Sprintf("%v ", node.JoinType))
 	}
 
-	builder.WriteString(node[NODE_TYPE].(string))
+	builder.WriteString(node.NodeType)
 	return builder.String()
 }
 
-func (s *Summary) scopes(node Node) NodeScopes {
-	operation := node[NODE_TYPE].(string)
-	op, ok := operationsMap[operation]
-	if !ok {
-		op = operationsMap["Default"]
-	}
+func (s *Summary) scopes(node *PlanNode) (NodeScopes, error) {
+	op := getOperation(node)
 
-	return NodeScopes{
-		Table:     ConvertScopeToString(node[op.RelationName]),
-		Filters:   ConvertScopeToString(node[op.Filter]),
-		Index:     ConvertScopeToString(node[op.Index]),
-		Key:       ConvertScopeToString(node[op.Key]),
-		Condition: ConvertScopeToString(node[op.Condition]),
+	scopes := NodeScopes{}
+	for _, scope := range []struct {
+		value *string
+		key   string
+	}{
+		{&scopes.Table, op.RelationName},
+		{&scopes.Filters, op.Filter},
+		{&scopes.Index, op.Index},
+		{&scopes.Key, op.Key},
+		{&scopes.Condition, op.Condition},
+		{&scopes.Command, op.Command},
+	} {
+		value, err := scopeProperty(node.Path, scope.key, node.Get(scope.key))
+		if err != nil {
+			return NodeScopes{}, err
+		}
+		*scope.value = value
 	}
+
+	// Neither are operation specific, Postgres reports them for all the nodes that scan a relation
+	scopes.Schema = node.Schema
+	scopes.Alias = node.Alias
+
+	return scopes, nil
 }
 
-func (s *Summary) recurseCTEsNodes(ctesNodes map[string]Node, stats Stats) {
-	for cteName, node := range ctesNodes {
+// recurseCTEsNodes appends the CTEs sorted by name, the rows, and their short ids, don't depend on the map order
+func (s *Summary) recurseCTEsNodes(ctesNodes map[string]*PlanNode, stats Stats) error {
+	names := make([]string, 0, len(ctesNodes))
+	for cteName := range ctesNodes {
+		names = append(names, cteName)
+	}
+	sort.Strings(names)
+
+	for _, cteName := range names {
+		node := ctesNodes[cteName]
 		cte := s.ctes[cteName]
-		delete(node, IS_CTE_ROOT)
-		s.recurseNode(node, stats, cte.level+1, cte.id)
+		if err := s.recurseNode(node, stats, cte.level+1, cte.id); err != nil {
+			return err
+		}
 	}
-}
 
-func (s *Summary) computeNodeFingerprint(row PlanRow) string {
-	return ""
+	return nil
 }
@@ -0,0 +1,769 @@
+package pkg
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	textNodeHeaderRegex = regexp.MustCompile(
+		`^(.+?)` +
+			`(?:\s+\(cost=(\d+\.\d+)\.\.(\d+\.\d+) rows=(\d+(?:\.\d+)?) width=(\d+)\))?` +
+			`(?:\s+\((?:actual (?:time=(\d+\.\d+)\.\.(\d+\.\d+) )?rows=(\d+(?:\.\d+)?) loops=(\d+)|(never executed))\))?$`,
+	)
+	textJoinRegex       = regexp.MustCompile(`^(Hash|Merge|Nested Loop)(?: (Left|Right|Full|Semi|Anti|Right Semi|Right Anti))?(?: Join)?$`)
+	textModifyRegex     = regexp.MustCompile(`^(Insert|Update|Delete|Merge) on (.+)$`)
+	textCustomScanRegex = regexp.MustCompile(`^Custom Scan \(([^)]+)\)(?: on (.+))?$`)
+	textIndexScanRegex  = regexp.MustCompile(`^(Index Scan|Index Only Scan)( Backward)? using (\S+|"[^"]+")(?: on (.+))?$`)
+	textScanOnRegex     = regexp.MustCompile(`^(.+?) on (.+)$`)
+	textSubplanRegex    = regexp.MustCompile(`^(InitPlan|SubPlan) \d+|^CTE \S+`)
+	textWorkerRegex     = regexp.MustCompile(`^Worker (\d+):\s*(.*)$`)
+	textWorkerActuals   = regexp.MustCompile(`^actual (?:time=(\d+\.\d+)\.\.(\d+\.\d+) )?rows=(\d+(?:\.\d+)?) loops=(\d+)$`)
+	textTriggerRegex    = regexp.MustCompile(`^Trigger(?: (\S+))?(?: for constraint (\S+))?(?: on (\S+))?: time=(\d+\.\d+) calls=(\d+)$`)
+	textTimeRegex       = regexp.MustCompile(`^(\d+(?:\.\d+)?) ms$`)
+	textJITTimingRegex  = regexp.MustCompile(`(\w+) (\d+(?:\.\d+)?) ms`)
+	textPairsSplitRegex = regexp.MustCompile(`\s{2,}`)
+	textOriginallyRegex = regexp.MustCompile(`^(\d+) \(originally (\d+)\)$`)
+)
+
+// Keys whose text value is a comma separated list, FORMAT JSON reports them as arrays
+var textListProperties = map[string]bool{
+	OUTPUT:                     true,
+	SORT_KEY:                   true,
+	GROUP_KEY:                  true,
+	PRESORTED_KEY:              true,
+	"Conflict Arbiter Indexes": true,
+}
+
+var textJoinTypes = map[string]string{
+	"Hash":        HASH_JOIN,
+	"Merge":       MERGE_JOIN,
+	"Nested Loop": NESTED_LOOP,
+}
+
+var textAggregateStrategies = map[string]string{
+	"Aggregate":      "Plain",
+	"GroupAggregate": "Sorted",
+	"HashAggregate":  "Hashed",
+	"MixedAggregate": "Mixed",
+}
+
+type textFrameKind int
+
+const (
+	textFrameNode textFrameKind = iota
+	textFrameSubplan
+	textFrameWorker
+	textFrameSection
+)
+
+type textFrame struct {
+	kind   textFrameKind
+	indent int
+	node   Node
+	name   string
+}
+
+type textPlanParser struct {
+	plans []interface{}
+	plan  Node
+	stack []textFrame
+}
+
+// ParseTextPlan reconstructs the FORMAT JSON structure, one element per plan, from the TEXT output of EXPLAIN
+func ParseTextPlan(plan string) ([]interface{}, error) {
+	p := &textPlanParser{
+		plans: make([]interface{}, 0),
+	}
+
+	for i, line := range strings.Split(strings.ReplaceAll(plan, "\r\n", "\n"), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if err := p.parseLine(line); err != nil {
+			return nil, fmt.Errorf("could not parse text plan at line %v: %v", i+1, err)
+		}
+	}
+
+	if len(p.plans) == 0 {
+		return nil, fmt.Errorf("could not parse text plan: no plan found")
+	}
+
+	for _, plan := range p.plans {
+		textDistributedQueries(plan.(Node)["Plan"].(Node))
+	}
+
+	return p.plans, nil
+}
+
+func (p *textPlanParser) parseLine(line string) error {
+	content := strings.TrimLeft(line, " \t")
+	indent := len(line) - len(content)
+	content = strings.TrimRight(content, " \t")
+
+	if strings.HasPrefix(content, "->") {
+		return p.parseChildNode(indent, content)
+	}
+
+	p.pop(indent)
+
+	if len(p.stack) == 0 && isTextNodeHeader(content) {
+		return p.parseRootNode(indent, content)
+	}
+
+	if p.plan == nil {
+		return fmt.Errorf("expected a plan node, got %q", content)
+	}
+
+	if len(p.stack) == 0 {
+		return p.parseTopLevelProperty(indent, content)
+	}
+
+	frame := p.stack[len(p.stack)-1]
+	switch frame.kind {
+	case textFrameNode:
+		if textSubplanRegex.MatchString(content) {
+			p.stack = append(p.stack, textFrame{kind: textFrameSubplan, indent: indent, node: frame.node, name: content})
+			return nil
+		}
+		if match := textWorkerRegex.FindStringSubmatch(content); match != nil {
+			worker := textWorker(frame.node, match[1])
+			p.stack = append(p.stack, textFrame{kind: textFrameWorker, indent: indent, node: worker})
+			if match[2] == "" {
+				return nil
+			}
+			return parseTextProperty(worker, frame.node, match[2])
+		}
+		return parseTextProperty(frame.node, frame.node, content)
+	case textFrameWorker:
+		return parseTextProperty(frame.node, p.parentNode(), content)
+	case textFrameSection:
+		return parseTextSectionProperty(frame.node, frame.name, content)
+	default:
+		return fmt.Errorf("unexpected line %q", content)
+	}
+}
+
+// pop removes all the frames that can't be the parent of a line with the given indentation
+func (p *textPlanParser) pop(indent int) {
+	for len(p.stack) > 0 && p.stack[len(p.stack)-1].indent >= indent {
+		p.stack = p.stack[:len(p.stack)-1]
+	}
+}
+
+func (p *textPlanParser) parentNode() Node {
+	for i := len(p.stack) - 1; i >= 0; i-- {
+		if p.stack[i].kind == textFrameNode {
+			return p.stack[i].node
+		}
+	}
+	return nil
+}
+
+func (p *textPlanParser) parseRootNode(indent int, content string) error {
+	node, err := parseTextNodeHeader(content)
+	if err != nil {
+		return err
+	}
+
+	p.plan = Node{"Plan": node}
+	p.plans = append(p.plans, p.plan)
+	p.stack = []textFrame{{kind: textFrameNode, indent: indent, node: node}}
+
+	return nil
+}
+
+func (p *textPlanParser) parseChildNode(indent int, content string) error {
+	p.pop(indent)
+	if len(p.stack) == 0 {
+		return fmt.Errorf("child node %q without a parent", content)
+	}
+
+	header := strings.TrimLeft(strings.TrimPrefix(content, "->"), " ")
+	node, err := parseTextNodeHeader(header)
+	if err != nil {
+		return err
+	}
+
+	frame := p.stack[len(p.stack)-1]
+	parent := frame.node
+	switch frame.kind {
+	case textFrameSubplan:
+		node[SUBPLAN_NAME] = frame.name
+		node[PARENT_RELATIONSHIP] = "InitPlan"
+		if strings.HasPrefix(frame.name, "SubPlan") {
+			node[PARENT_RELATIONSHIP] = "SubPlan"
+		}
+	case textFrameNode:
+		node[PARENT_RELATIONSHIP] = textParentRelationship(parent)
+	default:
+		return fmt.Errorf("unexpected child node %q", content)
+	}
+
+	children, _ := parent[PLANS_PROP].([]interface{})
+	parent[PLANS_PROP] = append(children, node)
+
+	nodeIndent := indent + len(content) - len(header)
+	p.stack = append(p.stack, textFrame{kind: textFrameNode, indent: nodeIndent, node: node})
+
+	return nil
+}
+
+func (p *textPlanParser) parseTopLevelProperty(indent int, content string) error {
+	if content == "JIT:" || content == "Planning:" {
+		name := strings.TrimSuffix(content, ":")
+		section := Node{}
+		p.plan[name] = section
+		p.stack = append(p.stack, textFrame{kind: textFrameSection, indent: indent, node: section, name: name})
+		return nil
+	}
+
+	if match := textTriggerRegex.FindStringSubmatch(content); match != nil {
+		name := match[1]
+		if name == "" {
+			name = match[2]
+		}
+		trigger := Node{
+			"Trigger Name": name,
+			"Time":         parseTextNumber(match[4]),
+			"Calls":        parseTextNumber(match[5]),
+		}
+		if match[2] != "" {
+			trigger["Constraint Name"] = match[2]
+		}
+		if match[3] != "" {
+			trigger["Relation"] = match[3]
+		}
+
+		triggers, _ := p.plan["Triggers"].([]interface{})
+		p.plan["Triggers"] = append(triggers, trigger)
+		return nil
+	}
+
+	key, value, ok := strings.Cut(content, ": ")
+	if !ok {
+		return fmt.Errorf("unexpected line %q", content)
+	}
+	if key == "Total runtime" {
+		key = "Execution Time"
+	}
+	p.plan[key] = parseTextValue(key, value)
+
+	return nil
+}
+
+func isTextNodeHeader(content string) bool {
+	return strings.Contains(content, "(cost=") ||
+		strings.Contains(content, "(actual ") ||
+		strings.Contains(content, "(never executed)")
+}
+
+func parseTextNodeHeader(header string) (Node, error) {
+	match := textNodeHeaderRegex.FindStringSubmatch(header)
+	if match == nil {
+		return nil, fmt.Errorf("could not parse node %q", header)
+	}
+
+	node := Node{}
+	if match[2] != "" {
+		node[STARTUP_COST] = parseTextNumber(match[2])
+		node[TOTAL_COST] = parseTextNumber(match[3])
+		node[PLAN_ROWS] = parseTextNumber(match[4])
+		node[PLAN_WIDTH] = parseTextNumber(match[5])
+	}
+
+	if match[8] != "" {
+		if match[6] != "" {
+			node[ACTUAL_STARTUP_TIME] = parseTextNumber(match[6])
+			node[ACTUAL_TOTAL_TIME] = parseTextNumber(match[7])
+		}
+		node[ACTUAL_ROWS] = parseTextNumber(match[8])
+		node[ACTUAL_LOOPS] = parseTextNumber(match[9])
+	}
+
+	if match[10] != "" {
+		node[ACTUAL_STARTUP_TIME] = 0.0
+		node[ACTUAL_TOTAL_TIME] = 0.0
+		node[ACTUAL_ROWS] = 0.0
+		node[ACTUAL_LOOPS] = 0.0
+	}
+
+	parseTextNodeLabel(node, match[1])
+
+	return node, nil
+}
+
+// parseTextNodeLabel splits labels like "Parallel Index Scan Backward using idx on public.t t1" into the node properties
+func parseTextNodeLabel(node Node, label string) {
+	node[PARALLEL_AWARE] = false
+	if strings.HasPrefix(label, "Parallel ") {
+		node[PARALLEL_AWARE] = true
+		label = strings.TrimPrefix(label, "Parallel ")
+	}
+	if strings.HasPrefix(label, "Async ") {
+		node["Async Capable"] = true
+		label = strings.TrimPrefix(label, "Async ")
+	}
+
+	if match := textJoinRegex.FindStringSubmatch(label); match != nil && (match[1] == "Nested Loop" || strings.HasSuffix(label, "Join")) {
+		node[NODE_TYPE] = textJoinTypes[match[1]]
+		node[JOIN_TYPE] = "Inner"
+		if match[2] != "" {
+			node[JOIN_TYPE] = match[2]
+		}
+		return
+	}
+
+	if match := textModifyRegex.FindStringSubmatch(label); match != nil {
+		node[NODE_TYPE] = "ModifyTable"
+		node["Operation"] = match[1]
+		setTextRelation(node, match[2])
+		return
+	}
+
+	if match := textCustomScanRegex.FindStringSubmatch(label); match != nil {
+		node[NODE_TYPE] = "Custom Scan"
+		node[CUSTOM_PLAN_PROVIDER] = match[1]
+		if match[2] != "" {
+			setTextRelation(node, match[2])
+		}
+		return
+	}
+
+	if match := textIndexScanRegex.FindStringSubmatch(label); match != nil {
+		node[NODE_TYPE] = match[1]
+		node["Scan Direction"] = "Forward"
+		if match[2] != "" {
+			node["Scan Direction"] = "Backward"
+		}
+		node[INDEX_NAME] = unquoteTextIdentifier(match[3])
+		if match[4] != "" {
+			setTextRelation(node, match[4])
+		}
+		return
+	}
+
+	if match := textScanOnRegex.FindStringSubmatch(label); match != nil {
+		node[NODE_TYPE] = match[1]
+		target := splitTextIdentifiers(match[2])
+
+		switch match[1] {
+		case BITMAP_INDEX_SCAN:
+			node[INDEX_NAME] = unquoteTextIdentifier(target[0])
+		case CTE_SCAN, "WorkTable Scan":
+			node[CTE_NAME] = unquoteTextIdentifier(target[0])
+			node[ALIAS] = textAlias(target)
+		case FUNCTION_SCAN:
+			node[FUNCTION_NAME] = unquoteTextIdentifier(target[0])
+			node[ALIAS] = textAlias(target)
+		case "Named Tuplestore Scan":
+			node["Tuplestore Name"] = unquoteTextIdentifier(target[0])
+			node[ALIAS] = textAlias(target)
+		case "Subquery Scan", "Values Scan", "Table Function Scan", "Result":
+			node[ALIAS] = unquoteTextIdentifier(target[0])
+		default:
+			setTextRelation(node, match[2])
+		}
+		return
+	}
+
+	node[NODE_TYPE] = label
+
+	for _, partialMode := range []string{"Partial", "Finalize"} {
+		if strings.HasPrefix(label, partialMode+" ") {
+			node["Partial Mode"] = partialMode
+			label = strings.TrimPrefix(label, partialMode+" ")
+		}
+	}
+	if strategy, ok := textAggregateStrategies[label]; ok {
+		node["Strategy"] = strategy
+		if node["Partial Mode"] == nil {
+			node["Partial Mode"] = "Simple"
+		}
+	}
+}
+
+// setTextRelation parses targets like `public.orders o`, schema is only printed with VERBOSE
+func setTextRelation(node Node, target string) {
+	identifiers := splitTextIdentifiers(target)
+	relation := identifiers[0]
+
+	if schema, name, ok := cutTextQualifiedName(relation); ok {
+		node[SCHEMA] = unquoteTextIdentifier(schema)
+		relation = name
+	}
+
+	node[RELATION_NAME] = unquoteTextIdentifier(relation)
+	node[ALIAS] = unquoteTextIdentifier(relation)
+	if len(identifiers) > 1 {
+		node[ALIAS] = unquoteTextIdentifier(identifiers[1])
+	}
+}
+
+func textAlias(identifiers []string) string {
+	if len(identifiers) > 1 {
+		return unquoteTextIdentifier(identifiers[1])
+	}
+	return unquoteTextIdentifier(identifiers[0])
+}
+
+func textParentRelationship(parent Node) string {
+	switch parent[NODE_TYPE] {
+	case "Append", "Merge Append", "BitmapAnd", "BitmapOr", "Custom Scan":
+		return "Member"
+	case "Subquery Scan":
+		return "Subquery"
+	}
+
+	children, _ := parent[PLANS_PROP].([]interface{})
+	for _, child := range children {
+		relationship := child.(Node)[PARENT_RELATIONSHIP]
+		if relationship == "Outer" {
+			return "Inner"
+		}
+	}
+
+	return "Outer"
+}
+
+func textWorker(node Node, number string) Node {
+	workerNumber := parseTextNumber(number)
+
+	workers, _ := node[WORKERS].([]interface{})
+	for _, w := range workers {
+		if w.(Node)["Worker Number"] == workerNumber {
+			return w.(Node)
+		}
+	}
+
+	worker := Node{"Worker Number": workerNumber}
+	node[WORKERS] = append(workers, worker)
+
+	return worker
+}
+
+// parseTextProperty sets a "Key: value" line on target, owner is the plan node the line belongs to
+func parseTextProperty(target Node, owner Node, content string) error {
+	if match := textWorkerActuals.FindStringSubmatch(content); match != nil {
+		if match[1] != "" {
+			target[ACTUAL_STARTUP_TIME] = parseTextNumber(match[1])
+			target[ACTUAL_TOTAL_TIME] = parseTextNumber(match[2])
+		}
+		target[ACTUAL_ROWS] = parseTextNumber(match[3])
+		target[ACTUAL_LOOPS] = parseTextNumber(match[4])
+		return nil
+	}
+
+	key, value, ok := strings.Cut(content, ": ")
+	if !ok {
+		key, ok = strings.CutSuffix(content, ":")
+		if !ok {
+			return fmt.Errorf("unexpected line %q", content)
+		}
+	}
+
+	switch key {
+	case "Buffers":
+		parseTextBuffers(target, value)
+	case "I/O Timings":
+		parseTextIOTimings(target, value)
+	case "WAL":
+		for _, counter := range strings.Fields(value) {
+			name, amount, _ := strings.Cut(counter, "=")
+			target["WAL "+textWALCounters[name]] = parseTextNumber(amount)
+		}
+	case HEAP_BLOCKS:
+		parseTextHeapBlocks(target, value)
+	case FULL_SORT_GROUPS, PRE_SORTED_GROUPS:
+		parseTextSortGroups(target, key, value)
+	default:
+		if textPairsProperties[key] {
+			parseTextPairs(target, owner, content)
+		} else {
+			target[key] = parseTextValue(key, value)
+		}
+	}
+
+	return nil
+}
+
+// Keys that start a line carrying several properties
+var textPairsProperties = map[string]bool{
+	"Sort Method":        true,
+	"Buckets":            true,
+	"Batches":            true,
+	"Planned Partitions": true,
+	"Memory Usage":       true,
+	"Hits":               true,
+}
+
+var textWALCounters = map[string]string{
+	"records": "Records",
+	"fpi":     "FPI",
+	"bytes":   "Bytes",
+}
+
+// parseTextPairs handles lines carrying several properties separated by two spaces,
+// ie. "Buckets: 1024  Batches: 1  Memory Usage: 9kB"
+func parseTextPairs(target Node, owner Node, content string) {
+	for _, pair := range textPairsSplitRegex.Split(content, -1) {
+		key, value, _ := strings.Cut(pair, ": ")
+
+		switch key {
+		case "Sort Method":
+			target[SORT_METHOD] = value
+		case "Memory", "Disk":
+			target[SORT_SPACE_USED] = parseTextKilobytes(value)
+			target[SORT_SPACE_TYPE] = key
+		case "Buckets", "Batches":
+			name := "Hash " + key
+			if key == "Batches" && owner[NODE_TYPE] != HASH {
+				name = "HashAgg Batches"
+			}
+			if match := textOriginallyRegex.FindStringSubmatch(value); match != nil {
+				target[name] = parseTextNumber(match[1])
+				target["Original "+name] = parseTextNumber(match[2])
+			} else {
+				target[name] = parseTextNumber(value)
+				if owner[NODE_TYPE] == HASH {
+					target["Original "+name] = parseTextNumber(value)
+				}
+			}
+		case "Memory Usage":
+			target["Peak Memory Usage"] = parseTextKilobytes(value)
+		case "Disk Usage":
+			target["Disk Usage"] = parseTextKilobytes(value)
+		case "Hits", "Misses", "Evictions", "Overflows":
+			target["Cache "+key] = parseTextNumber(value)
+		default:
+			target[key] = parseTextValue(key, value)
+		}
+	}
+}
+
+// parseTextBuffers parses "shared hit=1 read=2 dirtied=3 written=4, local hit=5, temp read=6 written=7"
+func parseTextBuffers(target Node, value string) {
+	for _, group := range strings.Split(value, ",") {
+		fields := strings.Fields(group)
+		if len(fields) == 0 {
+			continue
+		}
+
+		kind := strings.ToUpper(fields[0][:1]) + fields[0][1:]
+		for _, counter := range fields[1:] {
+			name, amount, _ := strings.Cut(counter, "=")
+			target[fmt.Sprintf("%v %v Blocks", kind, strings.ToUpper(name[:1])+name[1:])] = parseTextNumber(amount)
+		}
+	}
+
+	for _, property := range []string{
+		SHARED_HIT_BLOCKS, SHARED_READ_BLOCKS, SHARED_DIRTIED_BLOCKS, SHARED_WRITTEN_BLOCKS,
+		LOCAL_HIT_BLOCKS, LOCAL_READ_BLOCKS, LOCAL_DIRTIED_BLOCKS, LOCAL_WRITTEN_BLOCKS,
+		TEMP_READ_BLOCKS, TEMP_WRITTEN_BLOCKS,
+	} {
+		if target[property] == nil {
+			target[property] = 0.0
+		}
+	}
+}
+
+// parseTextIOTimings parses "read=1.2 write=0.5", the PG15 "shared/local read=1.2 temp read=0.3" and the PG16+
+// "shared read=1.2, local read=0.1, temp read=0.3" forms, the keys are the ones FORMAT JSON reports
+func parseTextIOTimings(target Node, value string) {
+	for _, group := range strings.Split(value, ",") {
+		fields := strings.Fields(group)
+		prefix := ""
+		for len(fields) > 0 && !strings.Contains(fields[0], "=") {
+			if fields[0] != "shared/local" {
+				prefix = strings.ToUpper(fields[0][:1]) + fields[0][1:] + " "
+			}
+			fields = fields[1:]
+		}
+
+		for _, timing := range fields {
+			name, amount, _ := strings.Cut(timing, "=")
+			target[fmt.Sprintf("%vI/O %v Time", prefix, strings.ToUpper(name[:1])+name[1:])] = parseTextNumber(amount)
+		}
+	}
+}
+
+// parseTextHeapBlocks parses "exact=10 lossy=30" into the Exact and the Lossy Heap Blocks
+func parseTextHeapBlocks(target Node, value string) {
+	for _, counter := range strings.Fields(value) {
+		name, amount, _ := strings.Cut(counter, "=")
+		if name != "" {
+			target[strings.ToUpper(name[:1])+name[1:]+" "+HEAP_BLOCKS] = parseTextNumber(amount)
+		}
+	}
+}
+
+// parseTextSortGroups parses "Full-sort Groups: 1  Sort Method: quicksort  Average Memory: 26kB  Peak Memory: 26kB"
+func parseTextSortGroups(target Node, key string, value string) {
+	groups := Node{}
+	pairs := textPairsSplitRegex.Split(value, -1)
+	groups["Group Count"] = parseTextNumber(pairs[0])
+
+	for _, pair := range pairs[1:] {
+		name, v, _ := strings.Cut(pair, ": ")
+		switch name {
+		case "Sort Method", "Sort Methods":
+			groups["Sort Methods Used"] = splitTextList(v)
+		case "Average Memory", "Peak Memory", "Average Disk", "Peak Disk":
+			stat, space, _ := strings.Cut(name, " ")
+			spaceKey := "Sort Space " + space
+			if groups[spaceKey] == nil {
+				groups[spaceKey] = Node{}
+			}
+			groups[spaceKey].(Node)[stat+" Sort Space Used"] = parseTextKilobytes(v)
+		}
+	}
+
+	target[key] = groups
+}
+
+func parseTextSectionProperty(section Node, name string, content string) error {
+	key, value, ok := strings.Cut(content, ": ")
+	if !ok {
+		return fmt.Errorf("unexpected line %q in %v section", content, name)
+	}
+
+	if name != "JIT" {
+		return parseTextProperty(section, section, content)
+	}
+
+	switch key {
+	case "Functions":
+		section[key] = parseTextNumber(value)
+	case "Options":
+		options := Node{}
+		for _, option := range strings.Split(value, ",") {
+			optionName, enabled, _ := strings.Cut(strings.TrimSpace(option), " ")
+			options[optionName] = enabled == "true"
+		}
+		section[key] = options
+	case "Timing":
+		timing := Node{}
+		for _, match := range textJITTimingRegex.FindAllStringSubmatch(value, -1) {
+			if timing[match[1]] == nil {
+				timing[match[1]] = parseTextNumber(match[2])
+			}
+		}
+		section[key] = timing
+	default:
+		section[key] = parseTextValue(key, value)
+	}
+
+	return nil
+}
+
+func parseTextValue(key string, value string) interface{} {
+	if textListProperties[key] {
+		return splitTextList(value)
+	}
+
+	if match := textTimeRegex.FindStringSubmatch(value); match != nil {
+		return parseTextNumber(match[1])
+	}
+
+	if float, err := strconv.ParseFloat(value, 64); err == nil {
+		return float
+	}
+
+	switch value {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+
+	return value
+}
+
+func parseTextKilobytes(value string) interface{} {
+	return parseTextNumber(strings.TrimSuffix(value, "kB"))
+}
+
+// parseTextNumber keeps the value as it is when it's not a number, the PlanNode decoding reports
+// it if the property must be numeric
+func parseTextNumber(value string) interface{} {
+	if float, err := strconv.ParseFloat(value, 64); err == nil {
+		return float
+	}
+	return value
+}
+
+// splitTextList splits on the commas that are not nested in parenthesis, brackets or quotes
+func splitTextList(value string) []interface{} {
+	items := make([]interface{}, 0)
+	depth := 0
+	quoted := false
+	start := 0
+
+	for i, c := range value {
+		switch {
+		case c == '"' || c == '\'':
+			quoted = !quoted
+		case quoted:
+		case c == '(' || c == '[':
+			depth++
+		case c == ')' || c == ']':
+			depth--
+		case c == ',' && depth == 0:
+			items = append(items, strings.TrimSpace(value[start:i]))
+			start = i + 1
+		}
+	}
+
+	return append(items, strings.TrimSpace(value[start:]))
+}
+
+// splitTextIdentifiers splits on the spaces that are not inside double quotes
+func splitTextIdentifiers(value string) []string {
+	identifiers := make([]string, 0)
+	quoted := false
+	start := 0
+
+	for i, c := range value {
+		switch {
+		case c == '"':
+			quoted = !quoted
+		case c == ' ' && !quoted:
+			if i > start {
+				identifiers = append(identifiers, value[start:i])
+			}
+			start = i + 1
+		}
+	}
+
+	if start < len(value) {
+		identifiers = append(identifiers, value[start:])
+	}
+	if len(identifiers) == 0 {
+		identifiers = append(identifiers, value)
+	}
+
+	return identifiers
+}
+
+func cutTextQualifiedName(name string) (string, string, bool) {
+	quoted := false
+	for i, c := range name {
+		switch {
+		case c == '"':
+			quoted = !quoted
+		case c == '.' && !quoted:
+			return name[:i], name[i+1:], true
+		}
+	}
+
+	return "", name, false
+}
+
+func unquoteTextIdentifier(identifier string) string {
+	if len(identifier) > 1 && strings.HasPrefix(identifier, `"`) && strings.HasSuffix(identifier, `"`) {
+		return strings.ReplaceAll(identifier[1:len(identifier)-1], `""`, `"`)
+	}
+	return identifier
+}
@@ -0,0 +1,165 @@
+package pkg
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTextPlan(t *testing.T) {
+	tests := []struct {
+		name string
+		plan string
+		path []int
+		want Node
+	}{
+		{
+			name: "root node with sort and buffers",
+			plan: ` Sort  (cost=100.50..101.00 rows=200 width=48) (actual time=5.123..5.200 rows=150 loops=1)
+   Sort Key: o.created_at DESC, o.id
+   Sort Method: quicksort  Memory: 35kB
+   Buffers: shared hit=120 read=30, temp read=5 written=5
+   ->  Seq Scan on orders o  (cost=0.00..20.00 rows=100 width=16) (actual time=0.010..0.500 rows=90 loops=1)
+ Planning Time: 0.321 ms
+ Execution Time: 5.400 ms`,
+			want: Node{
+				NODE_TYPE:           SORT,
+				STARTUP_COST:        100.5,
+				TOTAL_COST:          101.0,
+				PLAN_ROWS:           200.0,
+				ACTUAL_TOTAL_TIME:   5.2,
+				ACTUAL_LOOPS:        1.0,
+				SORT_KEY:            []interface{}{"o.created_at DESC", "o.id"},
+				SORT_METHOD:         "quicksort",
+				SORT_SPACE_USED:     35.0,
+				SORT_SPACE_TYPE:     "Memory",
+				SHARED_HIT_BLOCKS:   120.0,
+				SHARED_READ_BLOCKS:  30.0,
+				LOCAL_HIT_BLOCKS:    0.0,
+				TEMP_WRITTEN_BLOCKS: 5.0,
+			},
+		},
+		{
+			name: "join type and parallel index scan with workers",
+			plan: `Hash Left Join  (cost=30.00..90.00 rows=200 width=48) (actual time=1.000..4.800 rows=150 loops=1)
+  Hash Cond: (o.customer_id = c.id)
+  ->  Gather  (cost=0.00..50.00 rows=200 width=40) (actual time=0.200..3.000 rows=150 loops=1)
+        Workers Planned: 2
+        Workers Launched: 2
+        ->  Parallel Index Scan Backward using orders_created_idx on public.orders o  (cost=0.29..40.00 rows=83 width=40) (actual time=0.050..2.000 rows=50 loops=3)
+              Index Cond: (created_at > now())
+              Rows Removed by Filter: 3
+              Worker 0:  actual time=0.040..1.900 rows=48 loops=1
+              Worker 1:  actual time=0.060..1.950 rows=52 loops=1
+  ->  Hash  (cost=20.00..20.00 rows=800 width=16) (actual time=0.700..0.700 rows=800 loops=1)
+        Buckets: 1024 (originally 512)  Batches: 1  Memory Usage: 45kB
+        ->  Seq Scan on customers c  (cost=0.00..20.00 rows=800 width=16) (never executed)`,
+			path: []int{0, 0},
+			want: Node{
+				NODE_TYPE:              INDEX_SCAN,
+				PARENT_RELATIONSHIP:    "Outer",
+				PARALLEL_AWARE:         true,
+				"Scan Direction":       "Backward",
+				INDEX_NAME:             "orders_created_idx",
+				RELATION_NAME:          "orders",
+				SCHEMA:                 "public",
+				ALIAS:                  "o",
+				INDEX_CONDITION:        "(created_at > now())",
+				ROWS_REMOVED_BY_FILTER: 3.0,
+				WORKERS: []interface{}{
+					Node{"Worker Number": 0.0, ACTUAL_STARTUP_TIME: 0.04, ACTUAL_TOTAL_TIME: 1.9, ACTUAL_ROWS: 48.0, ACTUAL_LOOPS: 1.0},
+					Node{"Worker Number": 1.0, ACTUAL_STARTUP_TIME: 0.06, ACTUAL_TOTAL_TIME: 1.95, ACTUAL_ROWS: 52.0, ACTUAL_LOOPS: 1.0},
+				},
+			},
+		},
+		{
+			name: "hash buckets and never executed child",
+			plan: `Hash Join  (cost=30.00..90.00 rows=200 width=48) (actual time=1.000..4.800 rows=150 loops=1)
+  Hash Cond: (o.customer_id = c.id)
+  ->  Seq Scan on orders o  (cost=0.00..50.00 rows=200 width=40) (actual time=0.200..3.000 rows=150 loops=1)
+  ->  Hash  (cost=20.00..20.00 rows=800 width=16) (actual time=0.700..0.700 rows=800 loops=1)
+        Buckets: 1024 (originally 512)  Batches: 1  Memory Usage: 45kB
+        ->  Seq Scan on customers c  (cost=0.00..20.00 rows=800 width=16) (never executed)`,
+			path: []int{1},
+			want: Node{
+				NODE_TYPE:               HASH,
+				PARENT_RELATIONSHIP:     "Inner",
+				"Hash Buckets":          1024.0,
+				"Original Hash Buckets": 512.0,
+				"Hash Batches":          1.0,
+				"Peak Memory Usage":     45.0,
+			},
+		},
+		{
+			name: "CTE and InitPlan subplans",
+			plan: `CTE Scan on recent r  (cost=20.01..22.01 rows=100 width=16) (actual time=0.011..0.600 rows=90 loops=1)
+  Filter: (id > $1)
+  CTE recent
+    ->  Seq Scan on orders  (cost=0.00..20.00 rows=100 width=16) (actual time=0.010..0.500 rows=90 loops=1)
+  InitPlan 2 (returns $1)
+    ->  Result  (cost=0.00..0.01 rows=1 width=4) (actual time=0.001..0.001 rows=1 loops=1)`,
+			path: []int{0},
+			want: Node{
+				NODE_TYPE:           SEQUENTIAL_SCAN,
+				PARENT_RELATIONSHIP: "InitPlan",
+				SUBPLAN_NAME:        "CTE recent",
+				RELATION_NAME:       "orders",
+				ALIAS:               "orders",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plans, err := ParseTextPlan(tt.plan)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			node := plans[0].(Node)["Plan"].(Node)
+			for _, i := range tt.path {
+				node = node[PLANS_PROP].([]interface{})[i].(Node)
+			}
+
+			for key, want := range tt.want {
+				if !reflect.DeepEqual(node[key], want) {
+					t.Errorf("ParseTextPlan() %v = %#v, want %#v", key, node[key], want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseTextPlan_TopLevel(t *testing.T) {
+	plan := `Insert on orders  (cost=0.00..0.01 rows=0 width=0) (actual time=0.050..0.050 rows=0 loops=1)
+  ->  Result  (cost=0.00..0.01 rows=1 width=16) (actual time=0.001..0.001 rows=1 loops=1)
+Planning Time: 0.030 ms
+Trigger for constraint orders_customer_fk: time=0.120 calls=3
+JIT:
+  Functions: 4
+  Options: Inlining false, Optimization false, Expressions true, Deforming true
+  Timing: Generation 0.500 ms, Inlining 0.000 ms, Optimization 0.300 ms, Emission 4.100 ms, Total 4.900 ms
+Execution Time: 5.400 ms`
+
+	plans, err := ParseTextPlan(plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := plans[0].(Node)
+	if got["Plan"].(Node)[NODE_TYPE] != "ModifyTable" || got["Plan"].(Node)["Operation"] != "Insert" {
+		t.Errorf("ParseTextPlan() root = %v", got["Plan"])
+	}
+	if got["Planning Time"] != 0.03 || got["Execution Time"] != 5.4 {
+		t.Errorf("ParseTextPlan() timings = %v, %v", got["Planning Time"], got["Execution Time"])
+	}
+	wantTriggers := []interface{}{Node{"Trigger Name": "orders_customer_fk", "Constraint Name": "orders_customer_fk", "Time": 0.12, "Calls": 3.0}}
+	if !reflect.DeepEqual(got["Triggers"], wantTriggers) {
+		t.Errorf("ParseTextPlan() triggers = %#v, want %#v", got["Triggers"], wantTriggers)
+	}
+	if got["JIT"].(Node)["Timing"].(Node)["Total"] != 4.9 {
+		t.Errorf("ParseTextPlan() JIT = %v", got["JIT"])
+	}
+
+	if _, err := ParseTextPlan("not a plan"); err == nil {
+		t.Errorf("ParseTextPlan() expected an error for invalid input")
+	}
+}
@@ -0,0 +1,238 @@
+package pkg
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+// The chunks of the hypertable N are named _hyper_N_M_chunk, the compressed ones compress_hyper_N_M_chunk where N is
+// the internal hypertable holding the compressed data
+var chunkNameRegex = regexp.MustCompile(`^(compress)?_hyper_(\d+)_\d+_chunk$`)
+
+func init() {
+	for _, provider := range []string{CHUNK_APPEND, CONSTRAINT_AWARE_APPEND} {
+		RegisterCustomScan(provider, OperationDescriptor{
+			RelationName: RELATION_NAME,
+			Key:          "Order",
+			Properties:   getChunkAppendProperties,
+		})
+	}
+	RegisterCustomScan(DECOMPRESS_CHUNK, OperationDescriptor{
+		RelationName: RELATION_NAME,
+		Filter:       FILTER,
+		Properties:   getDecompressChunkProperties,
+		Insights:     getDecompressChunkInsights,
+	})
+}
+
+func isCustomScan(node *PlanNode, provider string) bool {
+	return node.NodeType == CUSTOM_SCAN && node.Extra[CUSTOM_PLAN_PROVIDER] == provider
+}
+
+// hypertableName returns the hypertable a ChunkAppend or a ConstraintAwareAppend scans, empty for the other nodes
+func hypertableName(node *PlanNode) string {
+	switch {
+	case isCustomScan(node, CHUNK_APPEND):
+		return qualifiedName(node.Schema, node.RelationName)
+	case isCustomScan(node, CONSTRAINT_AWARE_APPEND):
+		name, _ := node.Extra[HYPERTABLE].(string)
+		return name
+	}
+	return ""
+}
+
+// chunkHypertable names the hypertable of a chunk from the id found in its name, e.g. _timescaledb_internal._hyper_1.
+// It's only used when the chunk isn't scanned by a ChunkAppend, which reports the name of the hypertable.
+func chunkHypertable(node *PlanNode) (string, bool) {
+	match := chunkNameRegex.FindStringSubmatch(node.RelationName)
+	if match == nil {
+		return "", false
+	}
+	return qualifiedName(node.Schema, fmt.Sprintf("_hyper_%v", match[2])), true
+}
+
+// hypertableChunks maps the scans of chunks to their hypertable, the compressed chunks read by a DecompressChunk
+// belong to the hypertable of the chunk being decompressed
+func hypertableChunks(node *PlanNode, hypertable string, parents map[*PlanNode]string) {
+	if name := hypertableName(node); name != "" {
+		hypertable = name
+	}
+
+	if _, ok := chunkHypertable(node); ok {
+		if hypertable == "" {
+			hypertable, _ = chunkHypertable(node)
+		}
+		parents[node] = hypertable
+	}
+
+	for _, subNode := range node.subNodes() {
+		hypertableChunks(subNode, hypertable, parents)
+	}
+}
+
+func decompressedRows(node *PlanNode) float64 {
+	return node.ActualRowsRevised + node.RowsRemovedByFilterRevised
+}
+
+// compressedBatches adds up the rows read from the compressed chunk, each of them holds a batch of up to 1000 rows
+func compressedBatches(node *PlanNode) float64 {
+	batches := 0.0
+	for _, child := range node.Plans {
+		if child.ParentRelationship != "InitPlan" && child.ParentRelationship != "SubPlan" {
+			batches += child.ActualRowsRevised
+		}
+	}
+	return batches
+}
+
+func getChunkAppendProperties(node *PlanNode) ([]Property, error) {
+	props := []Property{
+		{ID: "chunks", Name: "Chunks", Type: "float", ValueFloat: float64(len(appendMembers(node))), Kind: Quantity},
+	}
+
+	for _, property := range []Property{
+		{ID: "chunks_excluded_during_startup", Name: CHUNKS_EXCLUDED_DURING_STARTUP, Kind: Quantity},
+		{ID: "chunks_excluded_during_runtime", Name: CHUNKS_EXCLUDED_DURING_RUNTIME, Kind: Quantity},
+		{ID: "chunks_left_after_exclusion", Name: CHUNKS_LEFT_AFTER_EXCLUSION, Kind: Quantity},
+	} {
+		if node.Extra[property.Name] == nil {
+			continue
+		}
+
+		value, err := floatProperty(node.Path, node.Extra, property.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		property.Type = "float"
+		property.ValueFloat = value
+		props = append(props, property)
+	}
+
+	return props, nil
+}
+
+func getDecompressChunkProperties(node *PlanNode) ([]Property, error) {
+	props := make([]Property, 0)
+	if node.ActualLoops != nil {
+		props = append(props,
+			Property{ID: "compressed_batches", Name: "Compressed Batches", Type: "float", ValueFloat: compressedBatches(node), Kind: Quantity},
+			Property{ID: "rows_decompressed", Name: "Rows Decompressed", Type: "float", ValueFloat: decompressedRows(node), Kind: Quantity},
+		)
+	}
+
+	if node.Extra[BULK_DECOMPRESSION] != nil {
+		bulk, err := boolProperty(node.Path, node.Extra, BULK_DECOMPRESSION)
+		if err != nil {
+			return nil, err
+		}
+		props = append(props, Property{ID: "bulk_decompression", Name: BULK_DECOMPRESSION, Type: "string", ValueString: fmt.Sprintf("%v", bulk)})
+	}
+
+	return props, nil
+}
+
+func getDecompressChunkInsights(node *PlanNode) ([]string, []string, error) {
+	if node.RowsRemovedByFilterRevised == 0 || node.RowsRemovedByFilterRevised < node.ActualRowsRevised {
+		return nil, nil, nil
+	}
+
+	return []string{fmt.Sprintf("%v of %v decompressed rows were removed by the filter, filtering on a segmentby column "+
+		"would skip the batches before decompressing them", node.RowsRemovedByFilterRevised, decompressedRows(node))}, nil, nil
+}
+
+// ComputeHypertablesStats describes the TimescaleDB hypertables scanned by the plan, nil when there are none. The
+// chunks scanned outside of a ChunkAppend are grouped by the id of their hypertable, e.g. _hyper_1.
+func (s *StatsGather) ComputeHypertablesStats(node *PlanNode) (*HypertablesStats, error) {
+	parents := map[*PlanNode]string{}
+	hypertableChunks(node, "", parents)
+
+	hypertables := map[string]*HypertableStats{}
+	if err := s.computeHypertablesStats(node, parents, hypertables); err != nil {
+		return nil, err
+	}
+
+	if len(hypertables) == 0 {
+		return nil, nil
+	}
+
+	stats := &HypertablesStats{Hypertables: make([]HypertableStats, 0, len(hypertables))}
+	for _, hypertable := range hypertables {
+		sort.Strings(hypertable.Chunks)
+		stats.Hypertables = append(stats.Hypertables, *hypertable)
+	}
+	sort.Slice(stats.Hypertables, func(i, j int) bool {
+		return stats.Hypertables[i].Name < stats.Hypertables[j].Name
+	})
+
+	return stats, nil
+}
+
+func (s *StatsGather) computeHypertablesStats(node *PlanNode, parents map[*PlanNode]string, hypertables map[string]*HypertableStats) error {
+	hypertable := func(name string) *HypertableStats {
+		stats, ok := hypertables[name]
+		if !ok {
+			stats = &HypertableStats{Name: name, Chunks: make([]string, 0)}
+			hypertables[name] = stats
+		}
+		return stats
+	}
+
+	if name := hypertableName(node); name != "" {
+		stats := hypertable(name)
+		for _, property := range []struct {
+			value *float64
+			key   string
+		}{
+			{&stats.ChunksExcludedAtStartup, CHUNKS_EXCLUDED_DURING_STARTUP},
+			{&stats.ChunksExcludedAtRuntime, CHUNKS_EXCLUDED_DURING_RUNTIME},
+		} {
+			if node.Extra[property.key] == nil {
+				continue
+			}
+
+			value, err := floatProperty(node.Path, node.Extra, property.key)
+			if err != nil {
+				return err
+			}
+			*property.value += value
+		}
+	}
+
+	if name, ok := parents[node]; ok {
+		stats := hypertable(name)
+		stats.TotalTime += node.ExclusiveDuration
+
+		// The compressed chunks are accounted with the decompression
+		chunk := qualifiedName(node.Schema, node.RelationName)
+		if match := chunkNameRegex.FindStringSubmatch(node.RelationName); match[1] == "" && !contains(stats.Chunks, chunk) {
+			stats.Chunks = append(stats.Chunks, chunk)
+		}
+
+		if isCustomScan(node, DECOMPRESS_CHUNK) {
+			stats.Decompression.Chunks++
+			stats.Decompression.Batches += compressedBatches(node)
+			stats.Decompression.Rows += decompressedRows(node)
+			stats.Decompression.RowsRemovedByFilter += node.RowsRemovedByFilterRevised
+			stats.Decompression.Time += node.ExclusiveDuration
+		}
+	}
+
+	for _, subNode := range node.subNodes() {
+		if err := s.computeHypertablesStats(subNode, parents, hypertables); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package pkg
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExplain_Hypertables(t *testing.T) {
+	tests := []struct {
+		name       string
+		plan       string
+		want       *HypertablesStats
+		wantTables map[string]string
+	}{
+		{
+			name: "chunk append",
+			plan: `[{"Plan":{"Node Type":"Custom Scan","Custom Plan Provider":"ChunkAppend","Relation Name":"metrics","Alias":"metrics","Total Cost":100,"Plan Rows":100,"Actual Startup Time":0,"Actual Total Time":10,"Actual Rows":600,"Actual Loops":1,` +
+				`"Startup Exclusion":true,"Runtime Exclusion":false,"Chunks excluded during startup":3,"Plans":[` +
+				`{"Node Type":"Seq Scan","Parent Relationship":"Member","Relation Name":"_hyper_1_1_chunk","Alias":"_hyper_1_1_chunk","Total Cost":10,"Plan Rows":100,"Actual Startup Time":0,"Actual Total Time":2,"Actual Rows":100,"Actual Loops":1},` +
+				`{"Node Type":"Custom Scan","Parent Relationship":"Member","Custom Plan Provider":"DecompressChunk","Relation Name":"_hyper_1_2_chunk","Alias":"_hyper_1_2_chunk","Total Cost":50,"Plan Rows":500,"Actual Startup Time":0,"Actual Total Time":7,"Actual Rows":500,"Actual Loops":1,` +
+				`"Filter":"(value > 10)","Rows Removed by Filter":1500,"Bulk Decompression":true,"Plans":[` +
+				`{"Node Type":"Seq Scan","Parent Relationship":"Outer","Relation Name":"compress_hyper_2_5_chunk","Alias":"compress_hyper_2_5_chunk","Total Cost":1,"Plan Rows":2,"Actual Startup Time":0,"Actual Total Time":1,"Actual Rows":2,"Actual Loops":1}]}]},"Execution Time":10}]`,
+			want: &HypertablesStats{Hypertables: []HypertableStats{{
+				Name:                    "metrics",
+				Chunks:                  []string{"_hyper_1_1_chunk", "_hyper_1_2_chunk"},
+				ChunksExcludedAtStartup: 3,
+				TotalTime:               9,
+				Decompression:           DecompressionStats{Chunks: 1, Batches: 2, Rows: 2000, RowsRemovedByFilter: 1500, Time: 6},
+			}}},
+			wantTables: map[string]string{
+				"metrics":                  "",
+				"_hyper_1_1_chunk":         "metrics",
+				"_hyper_1_2_chunk":         "metrics",
+				"compress_hyper_2_5_chunk": "metrics",
+			},
+		},
+		{
+			name: "chunks without chunk append",
+			plan: `Append  (cost=0.00..20.00 rows=200 width=8) (actual time=0.010..3.000 rows=200 loops=1)
+  ->  Seq Scan on _timescaledb_internal._hyper_3_7_chunk  (cost=0.00..10.00 rows=100 width=8) (actual time=0.010..1.000 rows=100 loops=1)
+  ->  Seq Scan on _timescaledb_internal._hyper_3_8_chunk  (cost=0.00..10.00 rows=100 width=8) (actual time=0.010..1.000 rows=100 loops=1)
+Execution Time: 3.000 ms`,
+			want: &HypertablesStats{Hypertables: []HypertableStats{{
+				Name:      "_timescaledb_internal._hyper_3",
+				Chunks:    []string{"_timescaledb_internal._hyper_3_7_chunk", "_timescaledb_internal._hyper_3_8_chunk"},
+				TotalTime: 2,
+			}}},
+			wantTables: map[string]string{
+				"_timescaledb_internal._hyper_3_7_chunk": "_timescaledb_internal._hyper_3",
+				"_timescaledb_internal._hyper_3_8_chunk": "_timescaledb_internal._hyper_3",
+			},
+		},
+		{
+			name:       "without hypertable",
+			plan:       `[{"Plan":{"Node Type":"Seq Scan","Relation Name":"metrics","Total Cost":10,"Plan Rows":10}}]`,
+			want:       nil,
+			wantTables: map[string]string{"metrics": ""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			explained, err := Explain(tt.plan, ExplainOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(explained.HypertablesStats, tt.want) {
+				t.Errorf("HypertablesStats = %+v, want %+v", explained.HypertablesStats, tt.want)
+			}
+
+			tables := map[string]string{}
+			for _, table := range explained.TablesStats.Tables {
+				tables[table.Name] = table.Parent
+			}
+			if !reflect.DeepEqual(tables, tt.wantTables) {
+				t.Errorf("TablesStats = %v, want %v", tables, tt.wantTables)
+			}
+		})
+	}
+}
@@ -1,5 +1,7 @@
 package pkg
 
+import "time"
+
 type Node = map[string]interface{}
 
 type JIT struct {
@@ -26,14 +28,19 @@ type StatsFromPlan struct {
 		PlanningTime  float64 `json:"Planning Time"`
 		JIT           *JIT    `json:"JIT,omitempty"`
 	} `json:"plan"`
-	ExecutionTime float64 `json:"Execution Time"`
-	PlanningTime  float64 `json:"Planning Time"`
-	JIT           *JIT    `json:"JIT,omitempty"`
-	Triggers      []struct {
-		Name  string  `json:"Trigger Name"`
-		Time  float64 `json:"Time"`
-		Calls string  `json:"Calls"`
-	} `json:"Triggers,omitempty"`
+	ExecutionTime float64           `json:"Execution Time"`
+	PlanningTime  float64           `json:"Planning Time"`
+	JIT           *JIT              `json:"JIT,omitempty"`
+	Triggers      []TriggerFromPlan `json:"Triggers,omitempty"`
+}
+
+// TriggerFromPlan Calls is a number in FORMAT JSON, but some tools export it as a string
+type TriggerFromPlan struct {
+	Name     string      `json:"Trigger Name"`
+	Relation string      `json:"Relation"`
+	Schema   string      `json:"Schema"`
+	Time     float64     `json:"Time"`
+	Calls    interface{} `json:"Calls"`
 }
 
 type Stats struct {
@@ -45,6 +52,14 @@ type Stats struct {
 	MaxBlocksRead    float64 `json:"max_blocks_read"`
 	MaxBlocksWritten float64 `json:"max_blocks_written"`
 	MaxBlocksHit     float64 `json:"max_blocks_hit"`
+	WALRecords       float64 `json:"wal_records"`
+	WALBytes         float64 `json:"wal_bytes"`
+	WALFPI           float64 `json:"wal_fpi"`
+	IOReadTime       float64 `json:"io_read_time"`
+	IOWriteTime      float64 `json:"io_write_time"`
+	IOTime           float64 `json:"io_time"`
+	CPUTime          float64 `json:"cpu_time"`
+	IOPercentage     float64 `json:"io_percentage"`
 }
 
 type Plans []struct {
@@ -55,14 +70,18 @@ type IndexesStats struct {
 	Indexes []IndexStats `json:"stats"`
 }
 
+// TablesStats keeps the partitions and the chunks as the relations they are, Partitioned rolls them up into the
+// table or the hypertable they're inferred to belong to
 type TablesStats struct {
-	Tables []TableStats `json:"stats"`
+	Tables      []TableStats            `json:"stats"`
+	Partitioned []PartitionedTableStats `json:"partitioned"`
 }
 
 type NodesStats struct {
 	Nodes []NodeStats `json:"stats"`
 }
 
+// Explained Cleanup tells what has been stripped around the plan before parsing it, e.g. the psql decoration
 type Explained struct {
 	Summary       []PlanRow    `json:"summary"`
 	Stats         Stats        `json:"stats"`
@@ -71,10 +90,171 @@ type Explained struct {
 	NodesStats    NodesStats   `json:"nodes_stats"`
 	JITStats      *JIT         `json:"jit_stats"`
 	TriggersStats *Triggers    `json:"triggers_stats"`
+	OutputStats   *OutputStats `json:"output_stats"`
+	SpillStats    *SpillStats  `json:"spill_stats"`
+
+	PartitionsStats *PartitionsStats `json:"partitions_stats"`
+	DML             *DMLStats        `json:"dml"`
+
+	DistributedStats *DistributedStats `json:"distributed_stats"`
+	HypertablesStats *HypertablesStats `json:"hypertables_stats"`
+
+	Cleanup CleanupReport `json:"cleanup"`
+}
+
+// OutputStats is only computed for VERBOSE plans. WideNodes carry many, or wide, columns several levels up
+// before they are used, UnusedColumns are fetched by scans but never used by the nodes above.
+type OutputStats struct {
+	WideNodes     []WideOutputNode    `json:"wide_nodes"`
+	UnusedColumns []UnusedColumnsNode `json:"unused_columns"`
+}
+
+type WideOutputNode struct {
+	Id             string   `json:"id"`
+	Type           string   `json:"type"`
+	Columns        int      `json:"columns"`
+	Width          float64  `json:"width"`
+	CarriedColumns []string `json:"carried_columns"`
+	Distance       int      `json:"distance"`
+}
+
+type UnusedColumnsNode struct {
+	Id      string   `json:"id"`
+	Type    string   `json:"type"`
+	Table   string   `json:"table"`
+	Alias   string   `json:"alias"`
+	Columns []string `json:"columns"`
+	Total   int      `json:"total"`
+}
+
+// SpillStats lists the hash aggregates reporting their memory usage (PG13+ EXPLAIN ANALYZE), DiskUsage is the
+// total written to disk by the ones that spilled, leaders and workers included
+type SpillStats struct {
+	Aggregates []HashAggregateStats `json:"aggregates"`
+	Spilled    int                  `json:"spilled"`
+	DiskUsage  float64              `json:"disk_usage"`
+}
+
+// HashAggregateStats sums the disk usage of the leader and of the workers, Batches and PeakMemoryUsage are
+// the largest of them, SpilledWorkers doesn't count the leader
+type HashAggregateStats struct {
+	Id                string  `json:"id"`
+	Operation         string  `json:"operation"`
+	Key               string  `json:"key"`
+	PlannedPartitions float64 `json:"planned_partitions"`
+	Batches           float64 `json:"batches"`
+	PeakMemoryUsage   float64 `json:"peak_memory_usage"`
+	DiskUsage         float64 `json:"disk_usage"`
+	SpilledWorkers    int     `json:"spilled_workers"`
+	Spilled           bool    `json:"spilled"`
+}
+
+// DMLStats describes the INSERT, UPDATE, DELETE and MERGE of a query. TriggersTime is the time of all the triggers
+// fired by the query, the one of each statement only adds up the triggers known to be defined on its table.
+type DMLStats struct {
+	Statements   []DMLStatement `json:"statements"`
+	TriggersTime float64        `json:"triggers_time"`
+	TotalTime    float64        `json:"total_time"`
+	WALRecords   float64        `json:"wal_records"`
+	WALBytes     float64        `json:"wal_bytes"`
+	WALFPI       float64        `json:"wal_fpi"`
+}
+
+type DMLStatement struct {
+	Id                 string   `json:"id"`
+	Command            string   `json:"command"`
+	Table              string   `json:"table"`
+	Alias              string   `json:"alias"`
+	ConflictResolution string   `json:"conflict_resolution"`
+	ArbiterIndexes     []string `json:"arbiter_indexes"`
+	TuplesInserted     float64  `json:"tuples_inserted"`
+	ConflictingTuples  float64  `json:"conflicting_tuples"`
+	TuplesUpdated      float64  `json:"tuples_updated"`
+	TuplesDeleted      float64  `json:"tuples_deleted"`
+	TuplesSkipped      float64  `json:"tuples_skipped"`
+	Time               float64  `json:"time"`
+	TriggersTime       float64  `json:"triggers_time"`
+	WAL                WAL      `json:"wal"`
+}
+
+// DistributedStats describes the Citus distributed queries. EXPLAIN only shows one of the tasks of a query unless
+// citus.explain_all_tasks is on, Nodes rolls up the shown tasks by the worker node that ran them.
+type DistributedStats struct {
+	Queries []DistributedQueryStats `json:"queries"`
+	Nodes   []WorkerNodeStats       `json:"nodes"`
+}
+
+type DistributedQueryStats struct {
+	Id         string  `json:"id"`
+	Provider   string  `json:"provider"`
+	TaskCount  int     `json:"task_count"`
+	TasksShown int     `json:"tasks_shown"`
+	TotalTime  float64 `json:"total_time"`
+}
+
+type WorkerNodeStats struct {
+	Node       string  `json:"node"`
+	Tasks      int     `json:"tasks"`
+	TotalTime  float64 `json:"total_time"`
+	MaxTime    float64 `json:"max_time"`
+	Rows       float64 `json:"rows"`
+	Percentage float64 `json:"percentage"`
+}
+
+type HypertablesStats struct {
+	Hypertables []HypertableStats `json:"hypertables"`
+}
+
+// HypertableStats describes the scans of the chunks of a TimescaleDB hypertable. The chunks are excluded by the
+// ChunkAppend when the executor starts, e.g. for the stable functions like now(), and at runtime for the parameters
+// of a Nested Loop. TotalTime adds up the exclusive time of the chunk scans, decompression included.
+type HypertableStats struct {
+	Name                    string             `json:"name"`
+	Chunks                  []string           `json:"chunks"`
+	ChunksExcludedAtStartup float64            `json:"chunks_excluded_at_startup"`
+	ChunksExcludedAtRuntime float64            `json:"chunks_excluded_at_runtime"`
+	TotalTime               float64            `json:"total_time"`
+	Decompression           DecompressionStats `json:"decompression"`
+}
+
+// DecompressionStats adds up the DecompressChunk nodes, Batches are the rows read from the compressed chunks and
+// Rows the rows they have been decompressed into, the ones removed by the filter included
+type DecompressionStats struct {
+	Chunks              int     `json:"chunks"`
+	Batches             float64 `json:"batches"`
+	Rows                float64 `json:"rows"`
+	RowsRemovedByFilter float64 `json:"rows_removed_by_filter"`
+	Time                float64 `json:"time"`
+}
+
+type PartitionsStats struct {
+	Appends []AppendStats `json:"appends"`
+}
+
+// AppendStats describes the members of an Append or a Merge Append. Subplans counts the members in the plan plus
+// the ones removed when the executor started, NeverExecuted the members pruned while running.
+type AppendStats struct {
+	Id              string           `json:"id"`
+	Operation       string           `json:"operation"`
+	Subplans        int              `json:"subplans"`
+	SubplansRemoved int              `json:"subplans_removed"`
+	NeverExecuted   int              `json:"never_executed"`
+	Partitions      []PartitionGroup `json:"partitions"`
+}
+
+// PartitionGroup gathers the members scanning the partitions of the same table, the parent is inferred from the
+// partition names
+type PartitionGroup struct {
+	Parent     string   `json:"parent"`
+	Partitions []string `json:"partitions"`
+	TotalTime  float64  `json:"total_time"`
 }
 
 type NodeScopes struct {
 	Table     string `json:"table"`
+	Schema    string `json:"schema"`
+	Alias     string `json:"alias"`
+	Command   string `json:"command"`
 	Filters   string `json:"filters"`
 	Index     string `json:"index"`
 	Key       string `json:"key"`
@@ -92,6 +272,7 @@ type Rows struct {
 	TotalAvg            float64 `json:"total_avg"`
 	PlannedRows         float64 `json:"planned_rows"`
 	Removed             float64 `json:"removed"`
+	RemovedByRecheck    float64 `json:"removed_by_recheck"`
 	EstimationFactor    float64 `json:"estimation_factor"`
 	EstimationDirection string  `json:"estimation_direction"`
 }
@@ -128,6 +309,27 @@ type Buffers struct {
 	EffectiveBlocksHits    float64 `json:"effective_blocks_hits"`
 }
 
+// WAL generated by a node, the exclusive values don't count the WAL generated by the sub nodes
+type WAL struct {
+	Records float64 `json:"records"`
+	Bytes   float64 `json:"bytes"`
+	FPI     float64 `json:"fpi"`
+
+	ExclusiveRecords float64 `json:"exclusive_records"`
+	ExclusiveBytes   float64 `json:"exclusive_bytes"`
+	ExclusiveFPI     float64 `json:"exclusive_fpi"`
+}
+
+// IO timings of a node, only reported with track_io_timing, Percentage is the share of the exclusive time
+// spent on exclusive IO
+type IO struct {
+	ReadTime           float64 `json:"read_time"`
+	WriteTime          float64 `json:"write_time"`
+	ExclusiveReadTime  float64 `json:"exclusive_read_time"`
+	ExclusiveWriteTime float64 `json:"exclusive_write_time"`
+	Percentage         float64 `json:"percentage"`
+}
+
 type Worker struct {
 	Number float64 `json:"number"`
 	Loops  float64 `json:"loops"`
@@ -141,10 +343,15 @@ type Workers struct {
 	List     [][]Property `json:"list"`
 }
 
+// Timings of a node, Inclusive, Exclusive and Startup take the loops and the workers into account,
+// ActualTotalTime and ActualStartupTime are the per loop averages reported by Postgres
 type Timings struct {
-	Inclusive     float64 `json:"inclusive"`
-	Exclusive     float64 `json:"exclusive"`
-	ExecutionTime float64 `json:"execution_time"`
+	Inclusive         float64 `json:"inclusive"`
+	Exclusive         float64 `json:"exclusive"`
+	Startup           float64 `json:"startup"`
+	ActualTotalTime   float64 `json:"actual_total_time"`
+	ActualStartupTime float64 `json:"actual_startup_time"`
+	ExecutionTime     float64 `json:"execution_time"`
 }
 
 type PlanRow struct {
@@ -163,23 +370,39 @@ type PlanRow struct {
 	Exclusive                  float64    `json:"exclusive"`
 	ExecutionTime              float64    `json:"execution_time"`
 	Buffers                    Buffers    `json:"buffers"`
+	WAL                        WAL        `json:"wal"`
+	IO                         IO         `json:"io"`
+	Output                     []string   `json:"output"`
+	Warnings                   []string   `json:"warnings"`
+	Infos                      []string   `json:"infos"`
 	SubPlanOf                  string     `json:"sub_plan_of"`
 	CteSubPlanOf               string     `json:"cte_sub_plan_of"`
+	Task                       *TaskInfo  `json:"task"`
 	ParentPlanId               string     `json:"parent_plan_id"`
 	DoesContainBuffers         bool       `json:"does_contain_buffers"`
+	DoesContainWAL             bool       `json:"does_contain_wal"`
 	Workers                    Workers    `json:"workers"`
 	NodeTypeSpecificProperties []Property `json:"node_type_specific_properties"`
 }
 
+// TaskInfo tags the nodes of the plans run by the Citus worker nodes, Index is the position of the task among the
+// ones shown by EXPLAIN
+type TaskInfo struct {
+	Index int    `json:"index"`
+	Node  string `json:"node"`
+}
+
 type Operation struct {
 	RelationName string `json:"relation_name"`
 	Index        string `json:"index"`
 	Filter       string `json:"filter"`
 	Key          string `json:"key"`
 	Condition    string `json:"condition"`
+	Command      string `json:"command"`
 
-	getSpecificProperties func(node Node) []Property
-	getWorkers            func(node Node) [][]Property
+	getSpecificProperties func(node *PlanNode) ([]Property, error)
+	getWorkers            func(node *PlanNode) ([][]Property, error)
+	getInsights           func(node *PlanNode) (warnings []string, infos []string, err error)
 }
 
 type Scope struct {
@@ -192,26 +415,52 @@ type IndexNode struct {
 	Type          string  `json:"type"`
 	ExclusiveTime float64 `json:"exclusive_time"`
 	Condition     string  `json:"condition"`
+	Alias         string  `json:"alias"`
 }
 
 type TableNode struct {
 	Id            string  `json:"id"`
 	Type          string  `json:"type"`
 	ExclusiveTime float64 `json:"exclusive_time"`
+	IOTime        float64 `json:"io_time"`
+	Alias         string  `json:"alias"`
 }
 
+// IndexStats and TableStats are keyed by the schema qualified name when the plan reports the schema (VERBOSE),
+// the aliases break the time down by the references of the relation in the query, e.g. in self joins
 type IndexStats struct {
-	Nodes      []IndexNode `json:"nodes"`
-	TotalTime  float64     `json:"total_time"`
-	Percentage float64     `json:"percentage"`
-	Name       string      `json:"name"`
+	Nodes      []IndexNode  `json:"nodes"`
+	TotalTime  float64      `json:"total_time"`
+	Percentage float64      `json:"percentage"`
+	Name       string       `json:"name"`
+	Schema     string       `json:"schema"`
+	Aliases    []AliasStats `json:"aliases"`
 }
 
 type TableStats struct {
-	Nodes      []TableNode `json:"nodes"`
-	TotalTime  float64     `json:"total_time"`
-	Percentage float64     `json:"percentage"`
-	Name       string      `json:"name"`
+	Nodes      []TableNode  `json:"nodes"`
+	TotalTime  float64      `json:"total_time"`
+	IOTime     float64      `json:"io_time"`
+	Percentage float64      `json:"percentage"`
+	Name       string       `json:"name"`
+	Schema     string       `json:"schema"`
+	Parent     string       `json:"parent"`
+	Aliases    []AliasStats `json:"aliases"`
+}
+
+type PartitionedTableStats struct {
+	Name       string   `json:"name"`
+	Partitions []string `json:"partitions"`
+	TotalTime  float64  `json:"total_time"`
+	IOTime     float64  `json:"io_time"`
+	Percentage float64  `json:"percentage"`
+}
+
+type AliasStats struct {
+	Alias      string  `json:"alias"`
+	Nodes      int     `json:"nodes"`
+	TotalTime  float64 `json:"total_time"`
+	Percentage float64 `json:"percentage"`
 }
 
 type NodeStats struct {
@@ -253,6 +502,9 @@ type ComparisonGeneralStats struct {
 	MaxBlocksRead    PropComparison `json:"max_blocks_read"`
 	MaxBlocksWritten PropComparison `json:"max_blocks_written"`
 	MaxBlocksHit     PropComparison `json:"max_blocks_hit"`
+	WALRecords       PropComparison `json:"wal_records"`
+	WALBytes         PropComparison `json:"wal_bytes"`
+	WALFPI           PropComparison `json:"wal_fpi"`
 }
 
 type NodeComparison struct {
@@ -270,6 +522,7 @@ type NodeComparison struct {
 	Exclusive       PropComparison       `json:"exclusive_time"`
 	ExecutionTime   PropComparison       `json:"execution_time"`
 	Buffers         BuffersComparison    `json:"buffers"`
+	WAL             WALComparison        `json:"wal"`
 }
 
 type NodeScopesComparison struct {
@@ -283,6 +536,7 @@ type RowsComparison struct {
 	Total            PropComparison `json:"total_rows"`
 	PlannedRows      PropComparison `json:"planned_rows"`
 	Removed          PropComparison `json:"removed_rows"`
+	RemovedByRecheck PropComparison `json:"removed_by_recheck_rows"`
 	EstimationFactor PropComparison `json:"rows_estimation_factor"`
 }
 
@@ -292,6 +546,12 @@ type BuffersComparison struct {
 	EffectiveBlocksHits    PropComparison `json:"effective_blocks_hits"`
 }
 
+type WALComparison struct {
+	Records PropComparison `json:"records"`
+	Bytes   PropComparison `json:"bytes"`
+	FPI     PropComparison `json:"fpi"`
+}
+
 type CostsComparison struct {
 	StartupCost PropComparison `json:"startup_cost"`
 	TotalCost   PropComparison `json:"total_cost"`
@@ -316,10 +576,12 @@ type PropStringComparison struct {
 }
 
 type Trigger struct {
-	Name    string  `json:"name"`
-	Time    float64 `json:"time"`
-	Calls   float64 `json:"calls"`
-	AvgTime float64 `json:"avg_time"`
+	Name     string  `json:"name"`
+	Relation string  `json:"relation"`
+	Schema   string  `json:"schema"`
+	Time     float64 `json:"time"`
+	Calls    float64 `json:"calls"`
+	AvgTime  float64 `json:"avg_time"`
 }
 
 type Triggers struct {
@@ -342,3 +604,26 @@ type ComparisonResponse struct {
 	Error      string `json:"error"`
 	Comparison string `json:"comparison"`
 }
+
+type AutoExplainEntry struct {
+	Timestamp time.Time `json:"timestamp"`
+	Duration  float64   `json:"duration"`
+	Database  string    `json:"database"`
+	User      string    `json:"user"`
+	QueryText string    `json:"query_text"`
+	Plan      string    `json:"plan"`
+}
+
+type ExplainedLogEntry struct {
+	AutoExplainEntry
+	Explained Explained `json:"explained"`
+	Error     string    `json:"error"`
+}
+
+type CleanupReport struct {
+	HeaderRemoved       bool `json:"header_removed"`
+	SeparatorRemoved    bool `json:"separator_removed"`
+	FooterRemoved       bool `json:"footer_removed"`
+	QuotesRemoved       bool `json:"quotes_removed"`
+	ContinuationMarkers int  `json:"continuation_markers"`
+}
@@ -2,172 +2,178 @@ package pkg
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
-	"reflect"
+	"math"
 	"strconv"
 	"strings"
 )
 
-func GetRootNodeFromPlans(plans string) (Node, error) {
+var ErrEmptyPlans = errors.New("plan does not contain any query")
+
+func GetRootNodeFromPlans(plans string) (*PlanNode, error) {
+	nodes, err := GetRootNodesFromPlans(plans)
+	if err != nil {
+		return nil, err
+	}
+
+	return nodes[0], nil
+}
+
+// GetRootNodesFromPlans returns the root node of every query, EXPLAIN produces more than one when
+// a script or a function runs several statements
+func GetRootNodesFromPlans(plans string) ([]*PlanNode, error) {
 	p := Plans{}
-	if err := json.Unmarshal([]byte(plans), &p); err != nil {
+	if err := json.Unmarshal([]byte(wrapJSONObject(plans)), &p); err != nil {
 		return nil, fmt.Errorf("could not unmarshal plan: %v", err)
 	}
 
-	return p[0].Plan, nil
-}
+	if len(p) == 0 {
+		return nil, ErrEmptyPlans
+	}
+
+	nodes := make([]*PlanNode, 0, len(p))
+	for i, plan := range p {
+		if plan.Plan == nil {
+			return nil, fmt.Errorf("query %v does not contain a plan", i)
+		}
 
-func getMaxBlocksRead(rootNode Node) float64 {
-	sum := 0.0
-	if rootNode[SHARED_READ_BLOCKS] != nil {
-		sum += rootNode[SHARED_READ_BLOCKS].(float64)
+		node, err := NewPlanNode(plan.Plan)
+		if err != nil && len(p) > 1 {
+			return nil, fmt.Errorf("query %v: %w", i, err)
+		}
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
 	}
-	if rootNode[TEMP_READ_BLOCKS] != nil {
-		sum += rootNode[TEMP_READ_BLOCKS].(float64)
+
+	return nodes, nil
+}
+
+func getStatsFromPlans(plans string) ([]StatsFromPlan, error) {
+	var p []StatsFromPlan
+	if err := json.Unmarshal([]byte(wrapJSONObject(plans)), &p); err != nil {
+		return nil, fmt.Errorf("could not unmarshal plan: %v", err)
 	}
-	if rootNode[LOCAL_READ_BLOCKS] != nil {
-		sum += rootNode[LOCAL_READ_BLOCKS].(float64)
+
+	if len(p) == 0 {
+		return nil, ErrEmptyPlans
 	}
 
-	return sum
+	return p, nil
 }
 
-func getMaxBlocksWritten(rootNode Node) float64 {
-	sum := 0.0
-	if rootNode[SHARED_WRITTEN_BLOCKS] != nil {
-		sum += rootNode[SHARED_WRITTEN_BLOCKS].(float64)
+func getMaxBlocksRead(rootNode *PlanNode) float64 {
+	if rootNode.Buffers == nil {
+		return 0.0
 	}
-	if rootNode[TEMP_WRITTEN_BLOCKS] != nil {
-		sum += rootNode[TEMP_WRITTEN_BLOCKS].(float64)
+	return rootNode.Buffers.SharedRead + rootNode.Buffers.TempRead + rootNode.Buffers.LocalRead
+}
+
+func getMaxBlocksWritten(rootNode *PlanNode) float64 {
+	if rootNode.Buffers == nil {
+		return 0.0
 	}
-	if rootNode[LOCAL_WRITTEN_BLOCKS] != nil {
-		sum += rootNode[LOCAL_WRITTEN_BLOCKS].(float64)
+	return rootNode.Buffers.SharedWritten + rootNode.Buffers.TempWritten + rootNode.Buffers.LocalWritten
+}
+
+func getMaxBlocksHits(rootNode *PlanNode) float64 {
+	if rootNode.Buffers == nil {
+		return 0.0
 	}
+	return rootNode.Buffers.LocalHit + rootNode.Buffers.SharedHit
+}
 
-	return sum
+func getExclusiveIOTime(node *PlanNode) float64 {
+	return node.ExclusiveIOReadTime + node.ExclusiveIOWriteTime
 }
 
-func getMaxBlocksHits(rootNode Node) float64 {
-	sum := 0.0
-	if rootNode[LOCAL_HIT_BLOCKS] != nil {
-		sum += rootNode[LOCAL_HIT_BLOCKS].(float64)
+// getPercentage caps the share at 100, the IO timings are summed over the workers while the durations are not
+func getPercentage(part, total float64) float64 {
+	if total <= 0.0 {
+		return 0.0
 	}
-	if rootNode[SHARED_HIT_BLOCKS] != nil {
-		sum += rootNode[SHARED_HIT_BLOCKS].(float64)
+	return math.Min(part/total*100, 100)
+}
+
+// qualifiedName prefixes a relation or an index with its schema, only known for VERBOSE plans
+func qualifiedName(schema string, name string) string {
+	if schema == "" {
+		return name
 	}
-	return sum
+	return schema + "." + name
 }
 
-func IsCTE(node Node) bool {
-	return node[PARENT_RELATIONSHIP] == "InitPlan" && strings.HasPrefix(node[SUBPLAN_NAME].(string), "CTE")
+func IsCTE(node *PlanNode) bool {
+	return node.ParentRelationship == "InitPlan" && strings.HasPrefix(node.SubplanName, "CTE")
 }
 
-func isSubPlan(node Node) bool {
-	return node[PARENT_RELATIONSHIP] == "SubPlan" && strings.HasPrefix(node[SUBPLAN_NAME].(string), "SubPlan")
+func isSubPlan(node *PlanNode) bool {
+	return node.ParentRelationship == "SubPlan" && strings.HasPrefix(node.SubplanName, "SubPlan")
 }
 
-func ConvertStringToFloat64(val string) float64 {
+func ConvertStringToFloat64(val string) (float64, error) {
 	float, err := strconv.ParseFloat(val, 64)
 	if err != nil {
-		panic(err)
+		return 0.0, fmt.Errorf("could not convert %q to float: %w", val, err)
 	}
 
-	return float
+	return float, nil
 }
 
-func ConvertToFloat64(val interface{}) float64 {
-	if val == nil {
-		return 0.0
-	}
-	if isFloat64(val) {
-		return val.(float64)
-	} else {
-		return ConvertStringToFloat64(val.(string))
+// ConvertToFloat64 accepts numbers and numbers formatted as strings, a missing value is 0
+func ConvertToFloat64(val interface{}) (float64, error) {
+	switch v := val.(type) {
+	case nil:
+		return 0.0, nil
+	case float64:
+		return v, nil
+	case string:
+		return ConvertStringToFloat64(v)
+	default:
+		return 0.0, fmt.Errorf("could not convert %T to float", val)
 	}
 }
 
-func ConvertScopeToString(prop interface{}) string {
+func ConvertScopeToString(prop interface{}) (string, error) {
 	if prop == nil {
-		return ""
+		return "", nil
 	}
 
 	switch r := prop.(type) {
 	case string:
-		return r
+		return r, nil
 	case []interface{}: // When Sorting we can have an array of sorting keys
 		marshal, err := json.MarshalIndent(r, "", "    ")
 		if err != nil {
-			panic(fmt.Errorf("could not marshal node operation scope into []string: %v", err))
+			return "", fmt.Errorf("could not marshal node operation scope into []string: %v", err)
 		}
-		return string(marshal)
+		return string(marshal), nil
 	default:
-		return ""
+		return "", nil
 	}
 }
 
-func isFloat64(val interface{}) bool {
-	typeOf := reflect.TypeOf(val).Kind()
-	return typeOf == reflect.Float64
+func getEffectiveBlocksRead(node *PlanNode) float64 {
+	return node.ExclusiveBuffers.LocalRead + node.ExclusiveBuffers.TempRead + node.ExclusiveBuffers.SharedRead
 }
 
-func isString(val interface{}) bool {
-	typeOf := reflect.TypeOf(val).Kind()
-	return typeOf == reflect.String
+func getEffectiveBlocksWritten(node *PlanNode) float64 {
+	return node.ExclusiveBuffers.LocalWritten + node.ExclusiveBuffers.TempWritten + node.ExclusiveBuffers.SharedWritten
 }
 
-func isBool(val interface{}) bool {
-	typeOf := reflect.TypeOf(val).Kind()
-	return typeOf == reflect.Bool
-}
-
-func getEffectiveBlocksRead(node Node) float64 {
-	sum := 0.0
-	if node[EXCLUSIVE+LOCAL_READ_BLOCKS] != nil {
-		sum += node[EXCLUSIVE+LOCAL_READ_BLOCKS].(float64)
-	}
-	if node[EXCLUSIVE+TEMP_READ_BLOCKS] != nil {
-		sum += node[EXCLUSIVE+TEMP_READ_BLOCKS].(float64)
-	}
-	if node[EXCLUSIVE+SHARED_READ_BLOCKS] != nil {
-		sum += node[EXCLUSIVE+SHARED_READ_BLOCKS].(float64)
-	}
-	return sum
-}
-
-func getEffectiveBlocksWritten(node Node) float64 {
-	sum := 0.0
-	if node[EXCLUSIVE+LOCAL_WRITTEN_BLOCKS] != nil {
-		sum += node[EXCLUSIVE+LOCAL_WRITTEN_BLOCKS].(float64)
-	}
-	if node[EXCLUSIVE+TEMP_WRITTEN_BLOCKS] != nil {
-		sum += node[EXCLUSIVE+TEMP_WRITTEN_BLOCKS].(float64)
-	}
-	if node[EXCLUSIVE+SHARED_WRITTEN_BLOCKS] != nil {
-		sum += node[EXCLUSIVE+SHARED_WRITTEN_BLOCKS].(float64)
-	}
-	return sum
-}
-
-func getEffectiveBlocksHits(node Node) float64 {
-	sum := 0.0
-	if node[EXCLUSIVE+LOCAL_HIT_BLOCKS] != nil {
-		sum += node[EXCLUSIVE+LOCAL_HIT_BLOCKS].(float64)
-	}
-	if node[EXCLUSIVE+SHARED_HIT_BLOCKS] != nil {
-		sum += node[EXCLUSIVE+SHARED_HIT_BLOCKS].(float64)
-	}
-	return sum
+func getEffectiveBlocksHits(node *PlanNode) float64 {
+	return node.ExclusiveBuffers.LocalHit + node.ExclusiveBuffers.SharedHit
 }
 
-func getRowsRemovedByFilter(node Node) float64 {
-	op := node[NODE_TYPE].(string)
+func getRowsRemovedByFilter(node *PlanNode) float64 {
 	removedByFilter := 0.0
-	filter, ok := filtersMap[op]
-	if ok {
-		removedByFilter = node[filter+REVISED].(float64)
+	if _, ok := filtersMap[node.NodeType]; ok {
+		removedByFilter = node.RowsRemovedByJoinFilterRevised
 	}
 	if removedByFilter == 0.0 {
-		removedByFilter = node[ROWS_REMOVED_BY_FILTER+REVISED].(float64)
+		removedByFilter = node.RowsRemovedByFilterRevised
 	}
 
 	return removedByFilter
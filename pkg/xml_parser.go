@@ -0,0 +1,240 @@
+package pkg
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// EXPLAIN replaces the characters that are not valid in a tag name with "-", these keys can't be
+// recovered by replacing "-" with a space
+var xmlKnownTags = map[string]string{}
+
+// Containers that hold a list even when they are empty
+var xmlListTags = map[string]bool{
+	"Plans":                    true,
+	"Workers":                  true,
+	"Triggers":                 true,
+	"Output":                   true,
+	"Sort-Key":                 true,
+	"Group-Key":                true,
+	"Presorted-Key":            true,
+	"Sort-Methods-Used":        true,
+	"Conflict-Arbiter-Indexes": true,
+	"Tasks":                    true,
+	"Subplans":                 true,
+}
+
+// FORMAT XML doesn't type the values, only the properties Postgres reports as numbers are converted, the others
+// are kept as strings even when they look like a number, e.g. a table named 2024
+var xmlNumericKeys = map[string]bool{
+	"Heap Fetches":                   true,
+	"Workers Planned":                true,
+	"Workers Launched":               true,
+	"Worker Number":                  true,
+	"Sort Space Used":                true,
+	"Average Sort Space Used":        true,
+	"Peak Sort Space Used":           true,
+	"Group Count":                    true,
+	"Subplans Removed":               true,
+	"Tuples Inserted":                true,
+	"Tuples Updated":                 true,
+	"Tuples Deleted":                 true,
+	"Tuples Skipped":                 true,
+	"Index Searches":                 true,
+	"Maximum Storage":                true,
+	"Memory Used":                    true,
+	"Memory Allocated":               true,
+	"Output Volume":                  true,
+	"Calls":                          true,
+	"Time":                           true,
+	"Functions":                      true,
+	"Generation":                     true,
+	"Inlining":                       true,
+	"Optimization":                   true,
+	"Emission":                       true,
+	"Deform":                         true,
+	"Total":                          true,
+	"Task Count":                     true,
+	"Chunks excluded during startup": true,
+	"Chunks excluded during runtime": true,
+	"Chunks left after exclusion":    true,
+}
+
+var xmlNumericSuffixes = []string{
+	" Cost", " Rows", " Width", " Time", " Loops", " Blocks", " Buckets", " Batches", " Partitions", " Usage", " Tuples",
+}
+
+var xmlNumericPrefixes = []string{
+	"Rows Removed by ", "Cache ", "WAL ",
+}
+
+func isXMLNumericKey(key string) bool {
+	if xmlNumericKeys[key] {
+		return true
+	}
+	for _, suffix := range xmlNumericSuffixes {
+		if strings.HasSuffix(key, suffix) {
+			return true
+		}
+	}
+	for _, prefix := range xmlNumericPrefixes {
+		if strings.HasPrefix(key, prefix) && key != CACHE_KEY && key != CACHE_MODE {
+			return true
+		}
+	}
+	return false
+}
+
+func init() {
+	for _, key := range []string{
+		FULL_SORT_GROUPS,
+		PRE_SORTED_GROUPS,
+		IO_READ_TIME,
+		IO_WRITE_TIME,
+		SHARED_IO_READ_TIME,
+		SHARED_IO_WRITE_TIME,
+		LOCAL_IO_READ_TIME,
+		LOCAL_IO_WRITE_TIME,
+		TEMP_IO_READ_TIME,
+		TEMP_IO_WRITE_TIME,
+		"One-Time Filter",
+	} {
+		xmlKnownTags[xmlTagName(key)] = key
+	}
+}
+
+type xmlElement struct {
+	name     string
+	children []*xmlElement
+	text     strings.Builder
+}
+
+// ParseXMLPlan decodes the output of EXPLAIN (FORMAT XML) into the same structure as FORMAT JSON
+func ParseXMLPlan(plan string) ([]interface{}, error) {
+	root, err := parseXMLElements(plan)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse xml plan: %v", err)
+	}
+	if root.name != "explain" {
+		return nil, fmt.Errorf("could not parse xml plan: expected <explain> root element, got <%v>", root.name)
+	}
+
+	plans := make([]interface{}, 0)
+	for _, query := range root.children {
+		plans = append(plans, xmlElementValue(query))
+	}
+
+	return plans, nil
+}
+
+func parseXMLElements(plan string) (*xmlElement, error) {
+	decoder := xml.NewDecoder(strings.NewReader(plan))
+	stack := make([]*xmlElement, 0)
+	var root *xmlElement
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			element := &xmlElement{name: t.Name.Local}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, element)
+			} else if root == nil {
+				root = element
+			}
+			stack = append(stack, element)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text.Write(t)
+			}
+		}
+	}
+
+	if root == nil {
+		return nil, fmt.Errorf("no element found")
+	}
+
+	return root, nil
+}
+
+func xmlElementValue(element *xmlElement) interface{} {
+	if len(element.children) == 0 {
+		if xmlListTags[element.name] {
+			return make([]interface{}, 0)
+		}
+		return parseXMLScalar(xmlKeyName(element.name), element.text.String())
+	}
+
+	if xmlListTags[element.name] || isXMLList(element) {
+		items := make([]interface{}, 0, len(element.children))
+		for _, child := range element.children {
+			items = append(items, xmlElementValue(child))
+		}
+		return items
+	}
+
+	node := Node{}
+	for _, child := range element.children {
+		node[xmlKeyName(child.name)] = xmlElementValue(child)
+	}
+
+	return node
+}
+
+// isXMLList recognises lists like <Plans><Plan>...</Plan></Plans> and <Sort-Key><Item>...</Item></Sort-Key>
+func isXMLList(element *xmlElement) bool {
+	for _, child := range element.children {
+		if child.name != "Item" && child.name+"s" != element.name {
+			return false
+		}
+	}
+	return true
+}
+
+func xmlKeyName(tag string) string {
+	if key, ok := xmlKnownTags[tag]; ok {
+		return key
+	}
+	return strings.ReplaceAll(tag, "-", " ")
+}
+
+func xmlTagName(key string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == '_' || r == '.' || (r >= '0' && r <= '9') || (r >= 'A' && r <= 'Z') || (r >= 'a' && r <= 'z') {
+			return r
+		}
+		return '-'
+	}, key)
+}
+
+func parseXMLScalar(key string, value string) interface{} {
+	switch value {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+
+	if !isXMLNumericKey(key) {
+		return value
+	}
+	if float, err := strconv.ParseFloat(value, 64); err == nil && !math.IsInf(float, 0) && !math.IsNaN(float) {
+		return float
+	}
+
+	return value
+}
@@ -0,0 +1,187 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type yamlLine struct {
+	number  int
+	indent  int
+	content string
+}
+
+// yamlPlanParser understands the subset of YAML emitted by EXPLAIN (FORMAT YAML): block mappings and sequences,
+// JSON quoted strings and plain numbers and booleans
+type yamlPlanParser struct {
+	lines []yamlLine
+	pos   int
+}
+
+// ParseYAMLPlan decodes the output of EXPLAIN (FORMAT YAML) into the same structure as FORMAT JSON
+func ParseYAMLPlan(plan string) ([]interface{}, error) {
+	p := &yamlPlanParser{}
+	for i, line := range strings.Split(strings.ReplaceAll(plan, "\r\n", "\n"), "\n") {
+		content := strings.TrimLeft(line, " ")
+		if strings.TrimSpace(content) == "" {
+			continue
+		}
+		p.lines = append(p.lines, yamlLine{
+			number:  i + 1,
+			indent:  len(line) - len(content),
+			content: strings.TrimRight(content, " \t"),
+		})
+	}
+
+	if len(p.lines) == 0 || !isYAMLSequenceItem(p.lines[0].content) {
+		return nil, fmt.Errorf("could not parse yaml plan: expected a list of plans")
+	}
+
+	plans, err := p.parseSequence(p.lines[0].indent)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse yaml plan: %v", err)
+	}
+	if p.pos < len(p.lines) {
+		return nil, fmt.Errorf("could not parse yaml plan: unexpected line %v %q", p.lines[p.pos].number, p.lines[p.pos].content)
+	}
+
+	return plans, nil
+}
+
+func (p *yamlPlanParser) parseBlock(indent int) (interface{}, error) {
+	if isYAMLSequenceItem(p.lines[p.pos].content) {
+		return p.parseSequence(indent)
+	}
+	return p.parseMapping(indent)
+}
+
+func (p *yamlPlanParser) parseSequence(indent int) ([]interface{}, error) {
+	items := make([]interface{}, 0)
+
+	for p.pos < len(p.lines) && p.lines[p.pos].indent == indent && isYAMLSequenceItem(p.lines[p.pos].content) {
+		line := p.lines[p.pos]
+		rest := strings.TrimLeft(strings.TrimPrefix(line.content, "-"), " ")
+
+		switch {
+		case rest == "":
+			p.pos++
+			if p.pos >= len(p.lines) || p.lines[p.pos].indent <= indent {
+				items = append(items, nil)
+				continue
+			}
+			item, err := p.parseBlock(p.lines[p.pos].indent)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		case isYAMLKey(rest):
+			// "- Node Type: ..." opens a mapping whose keys are aligned with the first one
+			p.lines[p.pos] = yamlLine{number: line.number, indent: indent + len(line.content) - len(rest), content: rest}
+			item, err := p.parseBlock(p.lines[p.pos].indent)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		default:
+			value, err := parseYAMLScalar(rest)
+			if err != nil {
+				return nil, fmt.Errorf("line %v: %v", line.number, err)
+			}
+			items = append(items, value)
+			p.pos++
+		}
+	}
+
+	return items, nil
+}
+
+func (p *yamlPlanParser) parseMapping(indent int) (Node, error) {
+	mapping := Node{}
+
+	for p.pos < len(p.lines) && p.lines[p.pos].indent == indent && !isYAMLSequenceItem(p.lines[p.pos].content) {
+		line := p.lines[p.pos]
+		if !isYAMLKey(line.content) {
+			return nil, fmt.Errorf("line %v: expected a key, got %q", line.number, line.content)
+		}
+
+		key, value := cutYAMLKey(line.content)
+		p.pos++
+
+		if value != "" {
+			scalar, err := parseYAMLScalar(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %v: %v", line.number, err)
+			}
+			mapping[key] = scalar
+			continue
+		}
+
+		// Empty groups, ie. "Triggers: " without any trigger
+		mapping[key] = nil
+		if p.pos >= len(p.lines) {
+			continue
+		}
+
+		next := p.lines[p.pos]
+		if next.indent > indent || (next.indent == indent && isYAMLSequenceItem(next.content)) {
+			block, err := p.parseBlock(next.indent)
+			if err != nil {
+				return nil, err
+			}
+			mapping[key] = block
+		}
+	}
+
+	if p.pos < len(p.lines) && p.lines[p.pos].indent > indent {
+		line := p.lines[p.pos]
+		return nil, fmt.Errorf("line %v: unexpected indentation for %q", line.number, line.content)
+	}
+
+	return mapping, nil
+}
+
+func isYAMLSequenceItem(content string) bool {
+	return content == "-" || strings.HasPrefix(content, "- ")
+}
+
+func isYAMLKey(content string) bool {
+	if strings.HasPrefix(content, `"`) {
+		return false
+	}
+	return strings.Contains(content, ": ") || strings.HasSuffix(content, ":")
+}
+
+func cutYAMLKey(content string) (string, string) {
+	if key, value, ok := strings.Cut(content, ": "); ok {
+		return key, strings.TrimSpace(value)
+	}
+	return strings.TrimSuffix(content, ":"), ""
+}
+
+// parseYAMLScalar decodes a value, EXPLAIN quotes every string with the JSON escaping rules
+func parseYAMLScalar(value string) (interface{}, error) {
+	if strings.HasPrefix(value, `"`) {
+		var s string
+		if err := json.Unmarshal([]byte(value), &s); err != nil {
+			return nil, fmt.Errorf("invalid string %v: %v", value, err)
+		}
+		return s, nil
+	}
+
+	switch value {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null", "~":
+		return nil, nil
+	}
+
+	if float, err := strconv.ParseFloat(value, 64); err == nil {
+		return float, nil
+	}
+
+	return value, nil
+}